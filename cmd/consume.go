@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"task1/internal/dlq"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Повторы временных сбоев: пауза удваивается от retryBackoff до
+// retryMaxBackoff, после retryAttempts попыток сообщение уходит в DLQ.
+const (
+	retryAttempts   = 5
+	retryBackoff    = time.Second
+	retryMaxBackoff = 30 * time.Second
+)
+
+// consume читает сообщения группы и коммитит смещение только после
+// обработки. handle возвращает ошибку, если сбой временный (хранилище
+// недоступно): сообщение повторяется с паузой, а если не удалось — уходит в
+// DLQ с причиной unavailable. Без DLQ повторы идут, пока хранилище не
+// ответит: сообщение не коммитится, пока не сохранено. Возвращает ошибку
+// чтения из Kafka; при отмене ctx — nil.
+func consume(ctx context.Context, reader *kafka.Reader, logger *slog.Logger, dlqWriter *dlq.Writer, handle func(context.Context, kafka.Message) error) error {
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !process(ctx, msg, logger, dlqWriter, handle) {
+			return nil
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// process обрабатывает сообщение с повторами. false — ctx отменён раньше,
+// чем сообщение обработано, коммитить его нельзя.
+func process(ctx context.Context, msg kafka.Message, logger *slog.Logger, dlqWriter *dlq.Writer, handle func(context.Context, kafka.Message) error) bool {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := handle(ctx, msg)
+		if err == nil {
+			return true
+		}
+		if attempt >= retryAttempts && dlqWriter.Enabled() {
+			if dlqWriter.Send(ctx, msg, dlq.ReasonUnavailable, err, nil) == nil {
+				return true
+			}
+		}
+		logger.WarnContext(ctx, "Временная ошибка обработки сообщения, повтор",
+			"error", err, "attempt", attempt, "backoff", backoff,
+			"kafka_partition", msg.Partition, "kafka_offset", msg.Offset)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, retryMaxBackoff)
+	}
+}
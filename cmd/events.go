@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"task1/internal/cache"
+	"task1/internal/dlq"
+	"task1/internal/logging"
+	"task1/internal/metrics"
+	"task1/internal/order"
+	"task1/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func createStatusEventsReader(brokers []string, topic, groupID string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		Topic:   topic,
+		GroupID: groupID,
+	})
+}
+
+func readStatusEvents(ctx context.Context, reader *kafka.Reader, logger *slog.Logger, repository order.Repository, cacheForOrders *cache.OrderCache, dlqWriter *dlq.Writer) {
+	err := consume(ctx, reader, logger, dlqWriter, func(ctx context.Context, msg kafka.Message) error {
+		return handleStatusEvent(ctx, msg, logger, repository, cacheForOrders, dlqWriter)
+	})
+	if err != nil {
+		logger.Error("Ошибка при получении события статуса", "error", err)
+		errChan <- err
+	}
+}
+
+// handleStatusEvent применяет событие и обновляет заказ в кеше. Ключ
+// сообщения — order_uid или track_number; он используется, если в теле
+// заказ не указан. Ошибка возвращается для временных сбоев хранилища.
+func handleStatusEvent(ctx context.Context, msg kafka.Message, logger *slog.Logger, repository order.Repository, cacheForOrders *cache.OrderCache, dlqWriter *dlq.Writer) error {
+	ctx, span := tracer.Start(tracing.ExtractKafka(ctx, &msg), "kafka.consume.status_event",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int("messaging.kafka.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		),
+	)
+	defer span.End()
+	ctx = logging.WithAttrs(ctx, "kafka_partition", msg.Partition, "kafka_offset", msg.Offset)
+
+	var event order.StatusEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		logger.ErrorContext(ctx, "Ошибка парсинга события статуса", "error", err)
+		metrics.StatusEvents.WithLabelValues("decode_error").Inc()
+		dlqWriter.Send(ctx, msg, dlq.ReasonDecodeError, err, nil)
+		return nil
+	}
+	if event.OrderUID == "" && event.TrackNumber == "" && len(msg.Key) > 0 {
+		if _, err := uuid.ParseBytes(msg.Key); err == nil {
+			event.OrderUID = string(msg.Key)
+		} else {
+			event.TrackNumber = string(msg.Key)
+		}
+	}
+	// без времени события порядок определяем по времени записи в Kafka
+	if event.EventTime.IsZero() {
+		event.EventTime = msg.Time
+	}
+	ctx = logging.WithAttrs(ctx, "event_id", event.EventID, "event_source", event.Source)
+
+	result, err := repository.ApplyEvent(order.WithActor(ctx, "kafka:"+msg.Topic), event)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		switch {
+		case errors.Is(err, order.ErrValidation):
+			logger.WarnContext(ctx, "Некорректное событие статуса", "error", err)
+			metrics.StatusEvents.WithLabelValues("invalid").Inc()
+			dlqWriter.Send(ctx, msg, dlq.ReasonRejected, err, nil)
+		case errors.Is(err, order.ErrNotFound):
+			logger.WarnContext(ctx, "Событие статуса для неизвестного заказа", "error", err)
+			metrics.StatusEvents.WithLabelValues("not_found").Inc()
+			dlqWriter.Send(ctx, msg, dlq.ReasonNotFound, err, nil)
+		case errors.Is(err, order.ErrInvalidTransition):
+			logger.WarnContext(ctx, "Событие статуса противоречит текущему статусу", "error", err)
+			metrics.StatusEvents.WithLabelValues("conflict").Inc()
+			dlqWriter.Send(ctx, msg, dlq.ReasonConflict, err, nil)
+		default:
+			// временный сбой: событие повторит consume
+			logger.ErrorContext(ctx, "Ошибка применения события статуса", "error", err)
+			metrics.StatusEvents.WithLabelValues("apply_error").Inc()
+			return err
+		}
+		return nil
+	}
+	ctx = logging.WithAttrs(ctx, "order_uid", result.OrderUID)
+	span.SetAttributes(attribute.String("order_uid", result.OrderUID),
+		attribute.Int("event.applied", result.Applied), attribute.Int("event.stale", result.Stale))
+	if result.Applied == 0 {
+		metrics.StatusEvents.WithLabelValues("stale").Inc()
+		logger.InfoContext(ctx, "Событие статуса устарело", "stale", result.Stale)
+		return nil
+	}
+	metrics.StatusEvents.WithLabelValues("applied").Inc()
+	logger.InfoContext(ctx, "Применено событие статуса", "applied", result.Applied, "stale", result.Stale)
+
+	ord, err := repository.FindById(ctx, result.OrderUID)
+	if err != nil {
+		logger.ErrorContext(ctx, "Ошибка обновления заказа в кеше", "error", err)
+		return nil
+	}
+	_, storeSpan := tracer.Start(ctx, "cache.store")
+	cacheForOrders.Store(ord)
+	storeSpan.End()
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"task1/internal/export"
+	"task1/internal/order"
+	"task1/internal/order/db"
+	"task1/pkg/client"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// runExport выгружает заказы из бд в файл или stdout:
+//
+//	order-sub export -format csv -customer-id test -from 2024-01-01T00:00:00Z -out orders.csv.gz
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatFlag := fs.String("format", "ndjson", "формат: ndjson, csv, xlsx")
+	out := fs.String("out", "-", "файл для записи, - для stdout")
+	gzipOut := fs.Bool("gzip", false, "сжать вывод gzip (включается сам для *.gz)")
+	customerID := fs.String("customer-id", "", "фильтр по customer_id")
+	deliveryService := fs.String("delivery-service", "", "фильтр по delivery_service")
+	trackNumber := fs.String("track-number", "", "фильтр по track_number")
+	from := fs.String("from", "", "date_created >= (RFC3339)")
+	to := fs.String("to", "", "date_created < (RFC3339)")
+	limit := fs.Int("limit", 0, "максимум заказов, 0 — без ограничения")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		logger.Error("Некорректный формат", "error", err)
+		return 2
+	}
+	filter := order.SearchFilter{
+		CustomerID:      *customerID,
+		DeliveryService: *deliveryService,
+		TrackNumber:     *trackNumber,
+		Limit:           *limit,
+	}
+	for _, bound := range []struct {
+		value string
+		dst   *time.Time
+	}{{*from, &filter.CreatedFrom}, {*to, &filter.CreatedTo}} {
+		if bound.value == "" {
+			continue
+		}
+		if *bound.dst, err = time.Parse(time.RFC3339, bound.value); err != nil {
+			logger.Error("Некорректная дата", "value", bound.value, "error", err)
+			return 2
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		godotenv.Load("example.env")
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	cluster, err := client.NewClusterFromEnv(ctx, logger)
+	if err != nil {
+		return 1
+	}
+	defer cluster.Close()
+	repo := db.NewRepository(cluster, logger, storageMode())
+
+	var dst io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logger.Error("Ошибка создания файла выгрузки", "error", err)
+			return 1
+		}
+		defer f.Close()
+		dst = f
+	}
+	if *gzipOut || strings.HasSuffix(*out, ".gz") {
+		gz := gzip.NewWriter(dst)
+		defer gz.Close()
+		dst = gz
+	}
+	writer, err := export.NewWriter(format, dst)
+	if err != nil {
+		logger.Error("Ошибка создания выгрузки", "error", err)
+		return 1
+	}
+	exported := 0
+	err = repo.Stream(ctx, filter, func(ord order.Order) error {
+		exported++
+		return writer.Write(ord)
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		logger.Error("Выгрузка заказов прервана", "error", err, "exported", exported)
+		return 1
+	}
+	logger.Info("Выгрузка заказов завершена", "format", format, "exported", exported)
+	return 0
+}
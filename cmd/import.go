@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"task1/internal/config"
+	"task1/internal/order"
+	"task1/internal/order/db"
+	"task1/internal/tracing"
+	"task1/pkg/client"
+
+	"github.com/joho/godotenv"
+	"github.com/segmentio/kafka-go"
+)
+
+// importLine — одна провалидированная строка входного файла.
+type importLine struct {
+	order order.Order
+	raw   []byte
+}
+
+// importResult — строка отчёта dry-run и ошибок импорта.
+type importResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Violations — нарушения бизнес-правил любого уровня
+	Violations []order.Violation `json:"violations,omitempty"`
+}
+
+// importCheckpoint хранит номер последней строки, записанной целиком.
+type importCheckpoint struct {
+	Input string `json:"input"`
+	Line  int    `json:"line"`
+}
+
+// runImport загружает заказы из NDJSON (можно сжатого gzip) в бд или Kafka:
+//
+//	order-sub import -in orders.ndjson.gz -batch 200 -checkpoint orders.ckpt
+//	order-sub import -in orders.ndjson -mode kafka -brokers kafka:9092 -topic my-topic
+//	order-sub import -in orders.ndjson -dry-run
+//
+// order_uid из файла не переносится: бд выдаёт новый, как и при приёме из Kafka.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	in := fs.String("in", "-", "входной NDJSON, - для stdin")
+	mode := fs.String("mode", "db", "куда писать: db или kafka")
+	batchSize := fs.Int("batch", 100, "размер пакета записи")
+	dryRun := fs.Bool("dry-run", false, "только проверить строки и вывести отчёт")
+	checkpointPath := fs.String("checkpoint", "", "файл контрольной точки для продолжения импорта")
+	brokers := fs.String("brokers", "kafka:9092", "брокеры Kafka через запятую")
+	topic := fs.String("topic", "my-topic", "топик Kafka")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if *mode != "db" && *mode != "kafka" {
+		logger.Error("Неизвестный режим импорта", "mode", *mode)
+		return 2
+	}
+	if *batchSize <= 0 {
+		*batchSize = 1
+	}
+	rules, err := importRules()
+	if err != nil {
+		logger.Error("Ошибка настройки бизнес-правил", "error", err)
+		return 2
+	}
+
+	src, closeSrc, err := openImportInput(*in)
+	if err != nil {
+		logger.Error("Ошибка открытия входного файла", "error", err)
+		return 1
+	}
+	defer closeSrc()
+
+	input := *in
+	if input != "-" {
+		input, _ = filepath.Abs(input)
+	}
+	resumeFrom := 0
+	if *checkpointPath != "" && !*dryRun {
+		ckpt, err := loadCheckpoint(*checkpointPath)
+		if err != nil {
+			logger.Error("Ошибка чтения контрольной точки", "error", err)
+			return 1
+		}
+		if ckpt.Line > 0 && ckpt.Input != input {
+			logger.Error("Контрольная точка относится к другому файлу", "checkpoint_input", ckpt.Input, "input", input)
+			return 1
+		}
+		resumeFrom = ckpt.Line
+		if resumeFrom > 0 {
+			logger.Info("Продолжаем импорт с контрольной точки", "line", resumeFrom)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	report := json.NewEncoder(os.Stdout)
+
+	var flush func(ctx context.Context, batch []importLine) error
+	switch {
+	case *dryRun:
+	case *mode == "kafka":
+		writer := &kafka.Writer{
+			Addr:         kafka.TCP(strings.Split(*brokers, ",")...),
+			Topic:        *topic,
+			BatchSize:    *batchSize,
+			RequiredAcks: kafka.RequireAll,
+		}
+		defer writer.Close()
+		flush = func(ctx context.Context, batch []importLine) error {
+			msgs := make([]kafka.Message, 0, len(batch))
+			for _, line := range batch {
+				msg := kafka.Message{Value: line.raw}
+				tracing.InjectKafka(ctx, &msg)
+				msgs = append(msgs, msg)
+			}
+			return writer.WriteMessages(ctx, msgs...)
+		}
+	default:
+		if err := godotenv.Load(); err != nil {
+			godotenv.Load("example.env")
+		}
+		cluster, err := client.NewClusterFromEnv(ctx, logger)
+		if err != nil {
+			return 1
+		}
+		defer cluster.Close()
+		repo := db.NewRepository(cluster, logger, storageMode())
+		flush = func(ctx context.Context, batch []importLine) error {
+			orders := make([]order.Order, 0, len(batch))
+			for _, line := range batch {
+				orders = append(orders, line.order)
+			}
+			_, err := repo.SaveBatch(order.WithActor(ctx, "import"), orders)
+			return err
+		}
+	}
+
+	var (
+		batch                              []importLine
+		valid, imported, invalid, lastLine int
+	)
+	commit := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := flush(ctx, batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		if *checkpointPath != "" {
+			return saveCheckpoint(*checkpointPath, importCheckpoint{Input: input, Line: lastLine})
+		}
+		return nil
+	}
+
+	reader := bufio.NewReader(src)
+	for number := 1; ; number++ {
+		raw, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			logger.Error("Ошибка чтения входного файла", "error", readErr, "line", number)
+			return 1
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && number > resumeFrom {
+			line, violations, err := parseImportLine(raw, rules)
+			if err != nil {
+				invalid++
+				report.Encode(importResult{Line: number, Status: "invalid", Error: err.Error(), Violations: violations})
+			} else if valid++; *dryRun {
+				report.Encode(importResult{Line: number, Status: "ok", Violations: violations})
+			} else {
+				batch = append(batch, line)
+			}
+		}
+		lastLine = number
+		if len(batch) >= *batchSize {
+			if err := commit(); err != nil {
+				logger.Error("Ошибка записи пакета", "error", err, "line", lastLine)
+				return 1
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+	}
+	if err := commit(); err != nil {
+		logger.Error("Ошибка записи пакета", "error", err, "line", lastLine)
+		return 1
+	}
+	logger.Info("Импорт завершён", "mode", *mode, "dry_run", *dryRun, "valid", valid, "imported", imported, "invalid", invalid, "skipped", resumeFrom)
+	if invalid > 0 {
+		return 3
+	}
+	return 0
+}
+
+func parseImportLine(raw []byte, rules *order.Rules) (importLine, []order.Violation, error) {
+	var ord order.Order
+	if err := json.Unmarshal(raw, &ord); err != nil {
+		return importLine{}, nil, fmt.Errorf("некорректный JSON: %w", err)
+	}
+	report := rules.Check(ord)
+	if err := report.Err(); err != nil {
+		return importLine{}, report.Violations, err
+	}
+	ord.Tags = report.Tags()
+	return importLine{order: ord, raw: append([]byte(nil), raw...)}, report.Violations, nil
+}
+
+// importRules берёт уровни правил из конфига сервиса; без конфига действуют
+// уровни по умолчанию.
+func importRules() (*order.Rules, error) {
+	cfg, err := config.MustLoad()
+	if err != nil {
+		return order.DefaultRules(), nil
+	}
+	return order.NewRules(cfg.Rules.Severities)
+}
+
+// storageMode — режим хранения из конфига; без конфига — relational.
+func storageMode() db.StorageMode {
+	cfg, err := config.MustLoad()
+	if err != nil {
+		return db.ModeRelational
+	}
+	mode, err := db.ParseStorageMode(cfg.Storage.Mode)
+	if err != nil {
+		return db.ModeRelational
+	}
+	return mode
+}
+
+// openImportInput открывает файл или stdin; gzip распознаётся по сигнатуре.
+func openImportInput(path string) (io.Reader, func(), error) {
+	var f *os.File
+	if path == "-" {
+		f = os.Stdin
+	} else {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	buffered := bufio.NewReader(f)
+	magic, _ := buffered.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return gz, func() { gz.Close(); f.Close() }, nil
+	}
+	return buffered, func() { f.Close() }, nil
+}
+
+func loadCheckpoint(path string) (importCheckpoint, error) {
+	var ckpt importCheckpoint
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ckpt, nil
+	}
+	if err != nil {
+		return ckpt, err
+	}
+	return ckpt, json.Unmarshal(data, &ckpt)
+}
+
+// saveCheckpoint пишет контрольную точку через временный файл, чтобы обрыв
+// процесса не оставил её наполовину записанной.
+func saveCheckpoint(path string, ckpt importCheckpoint) error {
+	data, err := json.Marshal(ckpt)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -3,26 +3,62 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"task1/internal/auth"
 	"task1/internal/cache"
 	"task1/internal/config"
+	"task1/internal/dlq"
+	"task1/internal/gql"
+	"task1/internal/grpcserv"
+	"task1/internal/health"
+	"task1/internal/logging"
+	"task1/internal/metrics"
 	"task1/internal/order"
 	"task1/internal/order/db"
+	"task1/internal/order/memory"
+	"task1/internal/redact"
+	"task1/internal/retention"
 	"task1/internal/serv"
+	"task1/internal/stream"
+	"task1/internal/tracing"
 	"task1/pkg/client"
 	"task1/pkg/migr"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// errChan — фатальные ошибки читателей Kafka, по первой main завершается.
+// Канал не закрывается: читатель может вернуть ошибку уже во время остановки.
 var errChan = make(chan error, 2)
 
+var tracer = otel.Tracer("task1/cmd")
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		}
+	}
+	storage := flag.String("storage", "postgres", "хранилище заказов: postgres или memory (без бд, для демо и тестов)")
+	flag.Parse()
 	stopChan := make(chan os.Signal, 1)
 	signal.Notify(stopChan, syscall.SIGINT, syscall.SIGINT)
 	logger := slog.Default()
@@ -34,44 +70,135 @@ func main() {
 		logger.Error("Ошибка загрузки переменных окружение .env", "error", err.Error())
 		err = godotenv.Load("example.env")
 		if err != nil {
-			logger.Error("Ошибка загрузки переменных окружение example.env", "error", err.Error())
-			errChan <- err
+			fatal(logger, "Ошибка загрузки переменных окружение example.env", err)
 		}
 
 	}
 	cfg, err := config.MustLoad()
 	if err != nil {
-		logger.Error("Ошибка при загрузке конфига", "error", err)
-		errChan <- err
+		fatal(logger, "Ошибка при загрузке конфига", err)
 	}
-	dbCLient, err := client.NewCLient(ctx, logger)
+	redactor, err := redact.New(cfg.Redaction)
 	if err != nil {
-		logger.Error("Ошибка подключение к бд", "error", err.Error())
-		errChan <- err
+		fatal(logger, "Ошибка настройки маскирования PII", err)
 	}
-	migrator := migr.Migrator{
-		Pool:   dbCLient,
-		Logger: logger,
+	logs, err := logging.New(cfg.Logging, os.Stdout, redactor.ReplaceAttr)
+	if err != nil {
+		fatal(logger, "Ошибка настройки логирования", err)
 	}
-	err = migrator.Migrate(os.Getenv("MIGRATE_PATH"))
+	logger = logs.Component("main")
+	slog.SetDefault(logs.Logger())
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, "order-sub")
 	if err != nil {
-		logger.Error("Ошибка миграции бд", "error", err)
-		errChan <- err
+		fatal(logger, "Ошибка настройки трассировки", err)
+	}
+	var (
+		dbCLient    *pgxpool.Pool
+		dbCluster   *client.Cluster
+		migrator    migr.Migrator
+		migratePath string
+		repository  order.Repository
+	)
+	switch *storage {
+	case "memory":
+		logger.Warn("Заказы хранятся в памяти и пропадут при остановке")
+		repository = metrics.NewInstrumentedRepository(memory.NewRepository())
+	case "postgres":
+		dbOptions, err := client.OptionsFromEnv()
+		if err != nil {
+			fatal(logger, "Ошибка настройки подключения к бд", err)
+		}
+		dbCluster, err = client.NewCluster(ctx, dbOptions, logs.Component("client"))
+		if err != nil {
+			fatal(logger, "Ошибка подключение к бд", err)
+		}
+		dbCLient = dbCluster.Primary()
+		logger.Info("Подключение к бд", "replicas", len(dbOptions.ReplicaDSNs), "read_your_writes", dbOptions.ReadYourWrites)
+		migrator = migr.Migrator{
+			Pool:   dbCLient,
+			Logger: logs.Component("migr"),
+		}
+		migratePath = os.Getenv("MIGRATE_PATH")
+		err = migrator.Migrate(migratePath)
+		if err != nil {
+			fatal(logger, "Ошибка миграции бд", err)
+		}
+		storageMode, err := db.ParseStorageMode(cfg.Storage.Mode)
+		if err != nil {
+			fatal(logger, "Ошибка настройки режима хранения", err)
+		}
+		metrics.RegisterPool(dbCLient)
+		repository = metrics.NewInstrumentedRepository(db.NewRepository(dbCluster, logs.Component("db"), storageMode))
+		retentionJob := retention.NewJob(cfg.Retention, db.NewPartitions(dbCLient, logs.Component("retention")), logs.Component("retention"))
+		go retentionJob.Run(ctx)
+	default:
+		err = fmt.Errorf("неизвестное хранилище %q", *storage)
+		fatal(logger, "Ошибка выбора хранилища", err)
 	}
 	reader := createKafkaReader()
 	defer reader.Close()
-	repository := db.NewRepository(dbCLient, logger)
-	cacheForOrders := cache.NewOrderCache(logger)
+	rules, err := order.NewRules(cfg.Rules.Severities)
+	if err != nil {
+		fatal(logger, "Ошибка настройки бизнес-правил", err)
+	}
+	var dlqWriter *dlq.Writer
+	if cfg.DLQ.Enabled {
+		dlqWriter = dlq.NewWriter(reader.Config().Brokers, cfg.DLQ.Topic, logs.Component("dlq"))
+		defer dlqWriter.Close()
+	}
+	cacheForOrders := cache.NewOrderCache(logs.Component("cache"))
 	cacheForOrders.RestoreFromDB(ctx, repository)
-	server := serv.NewServer(*cacheForOrders, logger, repository, cfg.Port)
+	authMiddleware, err := auth.New(cfg.Auth, logs.Component("auth"))
+	if err != nil {
+		fatal(logger, "Ошибка настройки аутентификации", err)
+	}
+	server := serv.NewServer(*cacheForOrders, logs.Component("serv"), repository, cfg, authMiddleware, redactor, rules)
+	checker := health.NewChecker(2 * time.Second)
+	if dbCLient != nil {
+		checker.Add("postgres", health.Postgres(dbCLient))
+		checker.Add("migrations", health.Migrations(&migrator, migratePath))
+	}
+	checker.Add("kafka", health.Kafka(reader.Config().Brokers))
+	checker.Add("cache", health.CacheWarm(cacheForOrders))
+	server.Handle("/healthz", http.HandlerFunc(checker.Liveness))
+	server.Handle("/readyz", http.HandlerFunc(checker.Readiness))
+	server.Handle("/metrics", promhttp.Handler())
+	hub := stream.NewHub(cfg.Stream.BufferSize, cfg.Stream.ClientBuffer, logs.Component("stream"))
+	server.Handle("/api/v1/orders/stream", stream.NewHandler(hub, cfg.Stream.Heartbeat, logs.Component("stream")))
+	graphqlHandler, err := gql.NewHandler(cacheForOrders, logs.Component("graphql"), repository, authMiddleware, redactor, cfg.GraphQL.MaxDepth, cfg.GraphQL.MaxComplexity)
+	if err != nil {
+		fatal(logger, "Ошибка настройки GraphQL", err)
+	}
+	server.Handle("/graphql", graphqlHandler)
 	go server.Start()
-	go readMessageFromKafka(ctx, reader, logger, repository)
+	adminServer := startAdminServer(cfg.HTTP, logs, logs.Component("admin"))
+	var grpcServer *grpcserv.Server
+	if cfg.GRPC.Enabled {
+		grpcServer = grpcserv.NewServer(cacheForOrders, logs.Component("grpc"), repository, hub, cfg.GRPC.Port, cfg.HTTP.BatchGetMaxIDs, authMiddleware, redactor)
+		go grpcServer.Start()
+	}
+	go readMessageFromKafka(ctx, reader, logs.Component("consumer"), repository, cacheForOrders, hub, rules, dlqWriter)
+	if cfg.StatusEvents.Enabled {
+		eventsReader := createStatusEventsReader(reader.Config().Brokers, cfg.StatusEvents.Topic, cfg.StatusEvents.GroupID)
+		defer eventsReader.Close()
+		go readStatusEvents(ctx, eventsReader, logs.Component("status_events"), repository, cacheForOrders, dlqWriter)
+	}
 	gracefulShutdown := func() {
 		logger.Info("GRACEFUL SHUTDOWN")
-		close(errChan)
 		close(stopChan)
-		dbCLient.Close()
+		if dbCluster != nil {
+			dbCluster.Close()
+		}
 		server.Stop(ctx)
+		if adminServer != nil {
+			adminServer.Shutdown(ctx)
+		}
+		if grpcServer != nil {
+			grpcServer.Stop(ctx)
+		}
+		if shutdownTracing != nil {
+			shutdownTracing(ctx)
+		}
 		cancel()
 	}
 	select {
@@ -86,6 +213,38 @@ func main() {
 	}
 }
 
+// fatal завершает процесс при ошибке настройки: продолжать запуск с
+// нулевыми зависимостями нельзя.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// startAdminServer обслуживает /admin/loglevel на cfg.AdminAddr. Аутентификации
+// у него нет, поэтому он не должен быть доступен снаружи: по умолчанию
+// слушает только localhost, а на порту API этих обработчиков нет.
+func startAdminServer(cfg config.HTTP, logs *logging.Logging, logger *slog.Logger) *http.Server {
+	if cfg.AdminAddr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/loglevel", logs.LevelHandler)
+	adminServer := &http.Server{
+		Addr:              cfg.AdminAddr,
+		Handler:           logging.RequestID(mux),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+	}
+	go func() {
+		if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Ошибка запуска админского сервера", "error", err)
+		}
+	}()
+	logger.Info("Админские обработчики доступны", "addr", cfg.AdminAddr)
+	return adminServer
+}
+
 func createKafkaReader() *kafka.Reader {
 	return kafka.NewReader(kafka.ReaderConfig{
 		Brokers: []string{"kafka:9092"},
@@ -94,28 +253,83 @@ func createKafkaReader() *kafka.Reader {
 	})
 }
 
-func readMessageFromKafka(ctx context.Context, reader *kafka.Reader, logger *slog.Logger, repository order.Repository) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
+func readMessageFromKafka(ctx context.Context, reader *kafka.Reader, logger *slog.Logger, repository order.Repository, cacheForOrders *cache.OrderCache, hub *stream.Hub, rules *order.Rules, dlqWriter *dlq.Writer) {
+	err := consume(ctx, reader, logger, dlqWriter, func(ctx context.Context, msg kafka.Message) error {
+		return handleMessage(ctx, msg, logger, repository, cacheForOrders, hub, rules, dlqWriter)
+	})
+	if err != nil {
+		logger.Error("Ошибка при получении", "error", err)
+		errChan <- err
+	}
+}
+
+// handleMessage сохраняет заказ из сообщения. Ошибка возвращается только для
+// временных сбоев хранилища: такое сообщение нужно повторить, остальные
+// уходят в DLQ с причиной.
+func handleMessage(ctx context.Context, msg kafka.Message, logger *slog.Logger, repository order.Repository, cacheForOrders *cache.OrderCache, hub *stream.Hub, rules *order.Rules, dlqWriter *dlq.Writer) error {
+	ctx, span := tracer.Start(tracing.ExtractKafka(ctx, &msg), "kafka.consume",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int("messaging.kafka.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		),
+	)
+	defer span.End()
+	ctx = logging.WithAttrs(ctx, "kafka_partition", msg.Partition, "kafka_offset", msg.Offset)
+
+	var ord order.Order
+	_, decodeSpan := tracer.Start(ctx, "order.decode")
+	err := json.Unmarshal(msg.Value, &ord)
+	tracing.End(decodeSpan, err)
+	if err != nil {
+		logger.ErrorContext(ctx, "Ошибка парсинга сообщения", "error", err)
+		metrics.KafkaMessages.WithLabelValues("decode_error").Inc()
+		dlqWriter.Send(ctx, msg, dlq.ReasonDecodeError, err, nil)
+		return nil
+	}
+	_, rulesSpan := tracer.Start(ctx, "order.rules")
+	report := rules.Check(ord)
+	err = report.Err()
+	tracing.End(rulesSpan, err)
+	metrics.ObserveViolations(report.Violations)
+	if err != nil {
+		logger.WarnContext(ctx, "Заказ отклонён бизнес-правилами", "error", err, "violations", report.Violations)
+		metrics.KafkaMessages.WithLabelValues("rejected").Inc()
+		dlqWriter.Send(ctx, msg, dlq.ReasonRejected, err, report.Violations)
+		span.SetStatus(codes.Error, err.Error())
+		return nil
+	}
+	if warnings := report.Warnings(); len(warnings) > 0 {
+		logger.WarnContext(ctx, "Заказ нарушает бизнес-правила", "violations", warnings)
+	}
+	ord.Tags = report.Tags()
+	ord.OrderUID, err = repository.Save(order.WithActor(ctx, "kafka:"+msg.Topic), ord)
+	if err != nil {
+		logger.ErrorContext(ctx, "Ошибка при сохранении в бд", "error", err, "order", ord)
+		span.SetStatus(codes.Error, err.Error())
+		switch {
+		case errors.Is(err, order.ErrValidation):
+			metrics.KafkaMessages.WithLabelValues("invalid").Inc()
+			dlqWriter.Send(ctx, msg, dlq.ReasonRejected, err, nil)
+		case errors.Is(err, order.ErrConflict):
+			metrics.KafkaMessages.WithLabelValues("conflict").Inc()
+			dlqWriter.Send(ctx, msg, dlq.ReasonConflict, err, nil)
 		default:
-			msg, err := reader.ReadMessage(ctx)
-			if err != nil {
-				logger.Error("Ошибка при получении", "error", err)
-				errChan <- err
-			}
-			var ord order.Order
-			if err := json.Unmarshal(msg.Value, &ord); err != nil {
-				logger.Error("Ошибка парсинга сообщения", "error", err)
-				continue
-			}
-			fmt.Println(ord)
-			ord.OrderUID, err = repository.Save(ctx, ord)
-			if err != nil {
-				logger.Error("Ошибка при сохранении в бд", "error", err)
-			}
-			logger.Info("Получен заказ")
+			// временный сбой: сообщение повторит consume
+			metrics.KafkaMessages.WithLabelValues("save_error").Inc()
+			return err
 		}
+		return nil
 	}
+	ord.Status = order.StatusCreated
+	span.SetAttributes(attribute.String("order_uid", ord.OrderUID))
+	ctx = logging.WithAttrs(ctx, "order_uid", ord.OrderUID)
+	_, storeSpan := tracer.Start(ctx, "cache.store")
+	cacheForOrders.Store(ord)
+	storeSpan.End()
+	hub.Publish(ord)
+	metrics.KafkaMessages.WithLabelValues("saved").Inc()
+	logger.InfoContext(ctx, "Получен заказ", "order", ord)
+	return nil
 }
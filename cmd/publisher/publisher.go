@@ -9,19 +9,26 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"task1/internal/money"
 	"task1/internal/order"
 	"time"
 
 	"task1/internal/config"
+	"task1/internal/logging"
+	"task1/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var orderChannel = make(chan order.Order, 100)
 var errChan = make(chan error, 2)
 
+var tracer = otel.Tracer("task1/cmd/publisher")
+
 func main() {
 
 	osSignal := make(chan os.Signal, 1)
@@ -37,10 +44,25 @@ func main() {
 		logger.Error("Ошибка при загрузке конфига", "error", err)
 		errChan <- err
 	}
+	logs, err := logging.New(cfg.Logging, os.Stdout, nil)
+	if err != nil {
+		logger.Error("Ошибка настройки логирования", "error", err)
+		errChan <- err
+	}
+	logger = logs.Component("publisher")
+	slog.SetDefault(logs.Logger())
 	ctx := context.Background()
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, "order-publisher")
+	if err != nil {
+		logger.Error("Ошибка настройки трассировки", "error", err)
+		errChan <- err
+	}
 	gracefulShutdown := func() {
 		close(orderChannel)
 		close(errChan)
+		if shutdownTracing != nil {
+			shutdownTracing(ctx)
+		}
 	}
 	writer := createKafkaWriter()
 	defer writer.Close()
@@ -55,8 +77,19 @@ func main() {
 	}
 
 }
+
+// generateRandomOrder собирает заказ, проходящий бизнес-правила: суммы
+// товаров, оплаты и трек-номера согласованы.
 func generateRandomOrder() order.Order {
 	trackNumber := fmt.Sprintf("WBIL%d", rand.Intn(10000))
+	items := generateRandomItems(trackNumber)
+	var goodsTotal int64
+	for _, item := range items {
+		minor, _ := item.TotalPrice.Minor()
+		goodsTotal += minor
+	}
+	deliveryCost := rand.Int63n(50000) + 10000
+	customFee := rand.Int63n(5000)
 	return order.Order{
 		TrackNumber:       trackNumber,
 		Entry:             "WBIL",
@@ -82,30 +115,32 @@ func generateRandomOrder() order.Order {
 			RequestID:    "",
 			Currency:     "USD",
 			Provider:     "wbpay",
-			Amount:       rand.Intn(10000) + 100,
+			Amount:       money.New(goodsTotal+deliveryCost+customFee, "USD"),
 			PaymentDT:    int(time.Now().Unix()),
 			Bank:         []string{"alpha", "sber", "tinkoff", "vtb"}[rand.Intn(4)],
-			DeliveryCost: rand.Intn(500) + 100,
-			GoodsTotal:   rand.Intn(500) + 50,
-			CustomFee:    rand.Intn(50),
+			DeliveryCost: money.New(deliveryCost, "USD"),
+			GoodsTotal:   money.New(goodsTotal, "USD"),
+			CustomFee:    money.New(customFee, "USD"),
 		},
-		Items: generateRandomItems(),
+		Items: items,
 	}
 }
-func generateRandomItems() []*order.Item {
+func generateRandomItems(trackNumber string) []*order.Item {
 	numItems := rand.Intn(3) + 1
 	items := make([]*order.Item, numItems)
 
 	for i := 0; i < numItems; i++ {
+		price := rand.Int63n(100000) + 1000
+		sale := rand.Intn(50)
 		items[i] = &order.Item{
 			ChrtID:      rand.Intn(10000000),
-			TrackNumber: fmt.Sprintf("WBIL%d", rand.Intn(10000)),
-			Price:       rand.Intn(1000) + 10,
+			TrackNumber: trackNumber,
+			Price:       money.New(price, "USD"),
 			Rid:         uuid.New().String(),
 			Name:        []string{"Phone", "Laptop", "Book", "Clothes", "Shoes"}[rand.Intn(5)],
-			Sale:        rand.Intn(50),
+			Sale:        sale,
 			Size:        fmt.Sprintf("%d", rand.Intn(5)),
-			TotalPrice:  rand.Intn(1000) + 50,
+			TotalPrice:  money.New(price*int64(100-sale)/100, "USD"),
 			NmID:        rand.Intn(1000000),
 			Brand:       []string{"Apple", "Samsung", "Nike", "Adidas", "Sony"}[rand.Intn(5)],
 			Status:      202,
@@ -135,14 +170,18 @@ func sendMessageToKafka(ctx context.Context, interavl time.Duration, writer *kaf
 				logger.Error("Ошибка маршалинга order", "error", err)
 				errChan <- err
 			}
-			err = writer.WriteMessages(ctx, kafka.Message{
+			spanCtx, span := tracer.Start(ctx, "kafka.produce", trace.WithSpanKind(trace.SpanKindProducer))
+			msg := kafka.Message{
 				Value: orderBytes,
-			})
+			}
+			tracing.InjectKafka(spanCtx, &msg)
+			err = writer.WriteMessages(spanCtx, msg)
+			tracing.End(span, err)
 			if err != nil {
-				logger.Error("Ошибка при отправке:", "error", err)
+				logger.ErrorContext(spanCtx, "Ошибка при отправке:", "error", err)
 				errChan <- err
 			}
-			logger.Info("Заказ отправлен")
+			logger.InfoContext(spanCtx, "Заказ отправлен")
 
 		}
 	}
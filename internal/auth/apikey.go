@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"task1/internal/config"
+)
+
+type APIKeyAuthenticator struct {
+	keys []config.APIKey
+}
+
+func NewAPIKeyAuthenticator(keys []config.APIKey) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		if scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "ApiKey") {
+			key = strings.TrimSpace(value)
+		}
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			return &Principal{Subject: k.Name, Roles: k.Roles}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"task1/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type verificationKey struct {
+	alg string
+	key any
+}
+
+type JWTAuthenticator struct {
+	keys       map[string]verificationKey
+	parser     *jwt.Parser
+	rolesClaim string
+}
+
+func NewJWTAuthenticator(cfg config.JWT) (*JWTAuthenticator, error) {
+	keys, err := loadJWKS(cfg.JWKSPath)
+	if err != nil {
+		return nil, err
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{"HS256", "RS256"})}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	rolesClaim := cfg.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+	return &JWTAuthenticator{keys: keys, parser: jwt.NewParser(opts...), rolesClaim: rolesClaim}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, raw, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(raw), claims, a.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	subject, _ := claims.GetSubject()
+	return &Principal{Subject: subject, Roles: claimStrings(claims[a.rolesClaim])}, nil
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := a.keys[kid]
+	if !ok && kid == "" && len(a.keys) == 1 {
+		for _, only := range a.keys {
+			k, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("неизвестный kid %q", kid)
+	}
+	if token.Method.Alg() != k.alg {
+		return nil, fmt.Errorf("алгоритм %s не подходит для ключа %q", token.Method.Alg(), kid)
+	}
+	return k.key, nil
+}
+
+func loadJWKS(path string) (map[string]verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("разбор JWKS %s: %w", path, err)
+	}
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("ключ %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = verificationKey{alg: "HS256", key: secret}
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("ключ %q: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("ключ %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = verificationKey{alg: "RS256", key: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}}
+		default:
+			return nil, fmt.Errorf("ключ %q: неподдерживаемый kty %q", k.Kid, k.Kty)
+		}
+	}
+	return keys, nil
+}
+
+func claimStrings(v any) []string {
+	switch roles := v.(type) {
+	case string:
+		return strings.Fields(roles)
+	case []any:
+		out := make([]string, 0, len(roles))
+		for _, r := range roles {
+			if s, ok := r.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"task1/internal/config"
+)
+
+var (
+	ErrNoCredentials      = errors.New("учётные данные не переданы")
+	ErrInvalidCredentials = errors.New("неверные учётные данные")
+	ErrForbidden          = errors.New("доступ запрещён")
+)
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Middleware проверяет учётные данные запроса и права роли на маршрут.
+// Нулевой *Middleware означает, что аутентификация выключена:
+// Wrap пропускает всё, а HasPermission всегда возвращает false —
+// без principal никаких дополнительных прав нет.
+type Middleware struct {
+	authenticators []Authenticator
+	policy         *Policy
+	publicPaths    []string
+	logger         *slog.Logger
+}
+
+func New(cfg config.Auth, logger *slog.Logger) (*Middleware, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	m := &Middleware{
+		policy:      NewPolicy(cfg.Roles),
+		publicPaths: cfg.PublicPaths,
+		logger:      logger,
+	}
+	if len(cfg.APIKeys) > 0 {
+		m.authenticators = append(m.authenticators, NewAPIKeyAuthenticator(cfg.APIKeys))
+	}
+	if cfg.JWT.JWKSPath != "" {
+		jwtAuth, err := NewJWTAuthenticator(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		m.authenticators = append(m.authenticators, jwtAuth)
+	}
+	return m, nil
+}
+
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.IsPublic(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := m.Authorize(r.Context(), r.Header, r.URL.Path)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer, ApiKey`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Authorize проверяет учётные данные из заголовков и доступ к маршруту.
+// Используется и HTTP-сервером, и gRPC-интерцептором (маршрут — полное имя метода).
+func (m *Middleware) Authorize(ctx context.Context, header http.Header, route string) (*Principal, error) {
+	principal, err := m.authenticate(&http.Request{Header: header})
+	if err != nil {
+		m.logger.InfoContext(ctx, "Запрос не прошёл аутентификацию", "route", route, "error", err)
+		return nil, err
+	}
+	if !m.policy.CanAccess(principal, route) {
+		m.logger.InfoContext(ctx, "Доступ запрещён", "subject", principal.Subject, "route", route)
+		return nil, ErrForbidden
+	}
+	return principal, nil
+}
+
+// IsPublic сообщает, что маршрут доступен без аутентификации.
+func (m *Middleware) IsPublic(route string) bool {
+	for _, public := range m.publicPaths {
+		if matchPath(public, route) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Middleware) HasPermission(ctx context.Context, perm string) bool {
+	if m == nil {
+		return false
+	}
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return false
+	}
+	return m.policy.HasPermission(principal, perm)
+}
+
+func (m *Middleware) authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range m.authenticators {
+		principal, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, ErrNoCredentials
+}
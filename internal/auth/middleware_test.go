@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"task1/internal/config"
+)
+
+func TestHasPermission(t *testing.T) {
+	m, err := New(config.Auth{
+		Enabled: true,
+		Roles: map[string]config.Role{
+			"support": {Permissions: []string{"delivery_pii"}},
+			"admin":   {Permissions: []string{"*"}},
+			"analyst": {},
+		},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var disabled *Middleware
+	ctx := context.Background()
+	with := func(roles ...string) context.Context {
+		return WithPrincipal(ctx, &Principal{Subject: "test", Roles: roles})
+	}
+	tests := []struct {
+		name string
+		m    *Middleware
+		ctx  context.Context
+		want bool
+	}{
+		{"выключенная аутентификация", disabled, with("admin"), false},
+		{"без principal", m, ctx, false},
+		{"роль с правом", m, with("support"), true},
+		{"роль со всеми правами", m, with("admin"), true},
+		{"роль без права", m, with("analyst"), false},
+		{"неизвестная роль", m, with("unknown"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.m.HasPermission(tt.ctx, "delivery_pii"); got != tt.want {
+			t.Errorf("%s: HasPermission = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"task1/internal/config"
+)
+
+type Principal struct {
+	Subject string
+	Roles   []string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+type Policy struct {
+	roles map[string]config.Role
+}
+
+func NewPolicy(roles map[string]config.Role) *Policy {
+	return &Policy{roles: roles}
+}
+
+func (p *Policy) CanAccess(principal *Principal, path string) bool {
+	for _, name := range principal.Roles {
+		role, ok := p.roles[name]
+		if !ok {
+			continue
+		}
+		for _, route := range role.Routes {
+			if matchPath(route, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Policy) HasPermission(principal *Principal, perm string) bool {
+	for _, name := range principal.Roles {
+		role, ok := p.roles[name]
+		if !ok {
+			continue
+		}
+		for _, granted := range role.Permissions {
+			if granted == perm || granted == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPath сравнивает путь запроса с шаблоном из конфига:
+// "*" совпадает с любым путём, шаблон с "/" на конце — префикс.
+func matchPath(pattern, path string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(path, pattern)
+	}
+	return pattern == path
+}
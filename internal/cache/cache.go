@@ -4,32 +4,41 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"sync/atomic"
+	"task1/internal/metrics"
 	"task1/internal/order"
 )
 
 type OrderCache struct {
 	store  map[string]order.Order
 	rw     *sync.RWMutex
+	warmed *atomic.Bool
 	logger *slog.Logger
 }
 
 func NewOrderCache(logger *slog.Logger) *OrderCache {
-	return &OrderCache{store: make(map[string]order.Order), rw: new(sync.RWMutex), logger: logger}
+	return &OrderCache{store: make(map[string]order.Order), rw: new(sync.RWMutex), warmed: new(atomic.Bool), logger: logger}
 }
 
 func (cache *OrderCache) Store(order order.Order) {
 	cache.rw.Lock()
 	cache.store[order.OrderUID] = order
+	size := len(cache.store)
 	cache.rw.Unlock()
+	metrics.CacheSize.Set(float64(size))
 	cache.logger.Info("Положили order в кеш")
 }
 
 func (cache *OrderCache) Load(id string) (order.Order, bool) {
 	cache.rw.RLock()
 	order, ok := cache.store[id]
-	if ok {
-		cache.logger.Info("Взяли order из кеша")
+	cache.rw.RUnlock()
+	if !ok {
+		metrics.CacheMisses.Inc()
+		return order, false
 	}
+	metrics.CacheHits.Inc()
+	cache.logger.Info("Взяли order из кеша")
 	return order, ok
 }
 
@@ -45,5 +54,13 @@ func (cache *OrderCache) RestoreFromDB(ctx context.Context, repos order.Reposito
 		cache.store[order.OrderUID] = order
 		cache.rw.Unlock()
 	}
+	cache.rw.RLock()
+	metrics.CacheSize.Set(float64(len(cache.store)))
+	cache.rw.RUnlock()
+	cache.warmed.Store(true)
 	cache.logger.Info("Загрузили orders from db")
 }
+
+func (cache *OrderCache) Warmed() bool {
+	return cache.warmed.Load()
+}
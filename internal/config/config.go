@@ -9,7 +9,142 @@ import (
 
 type Config struct {
 	TimeDurationPublisher time.Duration `yaml:"time_duration_publisher"`
-	Port int `yaml:"port"`
+	Port                  int           `yaml:"port"`
+	Auth                  Auth          `yaml:"auth"`
+	Redaction             Redaction     `yaml:"redaction"`
+	HTTP                  HTTP          `yaml:"http"`
+	RateLimit             RateLimit     `yaml:"rate_limit"`
+	Tracing               Tracing       `yaml:"tracing"`
+	Logging               Logging       `yaml:"logging"`
+	Stream                Stream        `yaml:"stream"`
+	GRPC                  GRPC          `yaml:"grpc"`
+	GraphQL               GraphQL       `yaml:"graphql"`
+	Rules                 Rules         `yaml:"rules"`
+	DLQ                   DLQ           `yaml:"dlq"`
+	StatusEvents          StatusEvents  `yaml:"status_events"`
+	Retention             Retention     `yaml:"retention"`
+	Storage               Storage       `yaml:"storage"`
+}
+
+// Storage — режим хранения заказов: relational, dual или document.
+type Storage struct {
+	Mode string `yaml:"mode" env:"STORAGE_MODE" env-default:"relational"`
+}
+
+// Retention — задача обслуживания секций заказов. Секции на PremakeMonths
+// вперёд создаются всегда; архивация и удаление старых — только при Enabled.
+type Retention struct {
+	Enabled       bool          `yaml:"enabled" env-default:"false"`
+	Interval      time.Duration `yaml:"interval" env-default:"1h"`
+	MaxAge        time.Duration `yaml:"max_age" env-default:"8760h"`
+	ArchiveDir    string        `yaml:"archive_dir" env-default:"./archive"`
+	PremakeMonths int           `yaml:"premake_months" env-default:"2"`
+}
+
+// StatusEvents — топик событий статусов от доставки, склада и оплаты.
+type StatusEvents struct {
+	Enabled bool   `yaml:"enabled" env-default:"true"`
+	Topic   string `yaml:"topic" env-default:"order-status-events"`
+	GroupID string `yaml:"group_id" env-default:"group-1-status"`
+}
+
+// Rules задаёт уровни бизнес-правил: reject, warn или tag.
+type Rules struct {
+	Severities map[string]string `yaml:"severities"`
+}
+
+type DLQ struct {
+	Enabled bool   `yaml:"enabled" env-default:"true"`
+	Topic   string `yaml:"topic" env-default:"my-topic-dlq"`
+}
+
+type GraphQL struct {
+	MaxDepth      int `yaml:"max_depth" env-default:"6"`
+	MaxComplexity int `yaml:"max_complexity" env-default:"1000"`
+}
+
+type GRPC struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	Port    int  `yaml:"port" env-default:"9090"`
+}
+
+type Stream struct {
+	BufferSize   int           `yaml:"buffer_size" env-default:"1000"`
+	ClientBuffer int           `yaml:"client_buffer" env-default:"64"`
+	Heartbeat    time.Duration `yaml:"heartbeat" env-default:"15s"`
+}
+
+type Logging struct {
+	Format     string            `yaml:"format" env:"LOG_FORMAT" env-default:"text"`
+	Level      string            `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	Components map[string]string `yaml:"components"`
+}
+
+type Tracing struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTLP_ENDPOINT" env-default:"localhost:4318"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env-default:"true"`
+	FilePath     string  `yaml:"file_path" env-default:"./traces.jsonl"`
+	SampleRatio  float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+type HTTP struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"5s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env-default:"10s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env-default:"15s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env-default:"16384"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" env-default:"1048576"`
+	BatchGetMaxIDs    int           `yaml:"batch_get_max_ids" env-default:"100"`
+	// AdminAddr — отдельный адрес для /admin/*, по умолчанию только localhost;
+	// пустой — админские обработчики выключены.
+	AdminAddr string `yaml:"admin_addr" env:"HTTP_ADMIN_ADDR" env-default:"127.0.0.1:9091"`
+}
+
+type RateLimit struct {
+	Enabled           bool             `yaml:"enabled"`
+	TrustForwardedFor bool             `yaml:"trust_forwarded_for"`
+	Default           Limit            `yaml:"default"`
+	Routes            map[string]Limit `yaml:"routes"`
+	// PreAuth — лимит на IP до аутентификации, общий для всех маршрутов.
+	PreAuth Limit `yaml:"pre_auth"`
+}
+
+type Limit struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+type Auth struct {
+	Enabled     bool            `yaml:"enabled"`
+	PublicPaths []string        `yaml:"public_paths"`
+	APIKeys     []APIKey        `yaml:"api_keys"`
+	JWT         JWT             `yaml:"jwt"`
+	Roles       map[string]Role `yaml:"roles"`
+}
+
+type APIKey struct {
+	Name  string   `yaml:"name"`
+	Key   string   `yaml:"key"`
+	Roles []string `yaml:"roles"`
+}
+
+type JWT struct {
+	JWKSPath   string `yaml:"jwks_path"`
+	Issuer     string `yaml:"issuer"`
+	Audience   string `yaml:"audience"`
+	RolesClaim string `yaml:"roles_claim" env-default:"roles"`
+}
+
+type Role struct {
+	Routes      []string `yaml:"routes"`
+	Permissions []string `yaml:"permissions"`
+}
+
+type Redaction struct {
+	UnmaskPermission string            `yaml:"unmask_permission" env-default:"delivery_pii"`
+	HashSalt         string            `yaml:"hash_salt" env:"PII_HASH_SALT"`
+	Fields           map[string]string `yaml:"fields"`
 }
 
 func MustLoad() (*Config, error) {
@@ -20,7 +155,7 @@ func MustLoad() (*Config, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return nil, err
 	}
-	var config Config	
+	var config Config
 	if err := cleanenv.ReadConfig(configPath, &config); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,94 @@
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"task1/internal/order"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Заголовки, которые добавляются к сообщению в DLQ. Исходные заголовки,
+// включая контекст трассировки, сохраняются.
+const (
+	HeaderReason     = "x-dlq-reason"
+	HeaderError      = "x-dlq-error"
+	HeaderViolations = "x-dlq-violations"
+	HeaderTopic      = "x-dlq-source-topic"
+	HeaderPartition  = "x-dlq-source-partition"
+	HeaderOffset     = "x-dlq-source-offset"
+)
+
+// Причины отправки в DLQ.
+const (
+	ReasonDecodeError = "decode_error"
+	ReasonRejected    = "rejected"
+	ReasonNotFound    = "not_found" // событие пришло раньше заказа, его можно переиграть позже
+	ReasonConflict    = "conflict"
+	// ReasonUnavailable — хранилище не ответило за все попытки; сообщение
+	// корректное, его нужно переиграть после восстановления.
+	ReasonUnavailable = "unavailable"
+)
+
+// Writer перекладывает необработанные сообщения в отдельный топик.
+// nil-Writer ничего не отправляет, так DLQ отключается конфигом.
+type Writer struct {
+	writer *kafka.Writer
+	logger *slog.Logger
+}
+
+func NewWriter(brokers []string, topic string, logger *slog.Logger) *Writer {
+	return &Writer{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			RequiredAcks: kafka.RequireAll,
+		},
+		logger: logger,
+	}
+}
+
+// Enabled сообщает, что DLQ включена и сообщения из Send не теряются.
+func (w *Writer) Enabled() bool {
+	return w != nil
+}
+
+// Send отправляет исходное сообщение с причиной, текстом ошибки и
+// нарушениями правил в заголовках. Ошибка отправки логируется и
+// возвращается; для отклонённых сообщений её можно не проверять —
+// консьюмер не должен вставать из-за DLQ.
+func (w *Writer) Send(ctx context.Context, msg kafka.Message, reason string, cause error, violations []order.Violation) error {
+	if w == nil {
+		return nil
+	}
+	headers := append([]kafka.Header(nil), msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderReason, Value: []byte(reason)},
+		kafka.Header{Key: HeaderTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	if cause != nil {
+		headers = append(headers, kafka.Header{Key: HeaderError, Value: []byte(cause.Error())})
+	}
+	if len(violations) > 0 {
+		encoded, _ := json.Marshal(violations)
+		headers = append(headers, kafka.Header{Key: HeaderViolations, Value: encoded})
+	}
+	err := w.writer.WriteMessages(ctx, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers})
+	if err != nil {
+		w.logger.ErrorContext(ctx, "Ошибка отправки сообщения в DLQ", "error", err, "reason", reason)
+		return err
+	}
+	w.logger.InfoContext(ctx, "Сообщение отправлено в DLQ", "reason", reason)
+	return nil
+}
+
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.writer.Close()
+}
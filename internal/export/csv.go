@@ -0,0 +1,39 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"task1/internal/order"
+)
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) Write(ord order.Order) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.w.WriteAll(flatten(ord))
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.w.Write(header)
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
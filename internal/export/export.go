@@ -0,0 +1,117 @@
+// Package export пишет заказы потоком в NDJSON, CSV и XLSX.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"task1/internal/order"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+	FormatXLSX   Format = "xlsx"
+)
+
+// Writer принимает заказы по одному; Close дописывает хвост файла.
+type Writer interface {
+	Write(ord order.Order) error
+	Close() error
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatNDJSON, FormatCSV, FormatXLSX:
+		return f, nil
+	case "":
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("неизвестный формат выгрузки %q", s)
+	}
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w), nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат выгрузки %q", format)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// FileName возвращает имя файла выгрузки вида orders-20060102-150405.csv.
+func (f Format) FileName(now time.Time) string {
+	return "orders-" + now.UTC().Format("20060102-150405") + "." + string(f)
+}
+
+// header — плоские колонки CSV/XLSX: одна строка на товар.
+var header = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature",
+	"customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city",
+	"delivery_address", "delivery_region", "delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider",
+	"payment_amount", "payment_dt", "payment_bank", "payment_delivery_cost",
+	"payment_goods_total", "payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name",
+	"item_sale", "item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+// numericColumns — индексы колонок header, которые в XLSX пишутся числами.
+var numericColumns = map[int]bool{
+	8: true, 22: true, 23: true, 25: true, 26: true, 27: true,
+	28: true, 30: true, 33: true, 35: true, 36: true, 38: true,
+}
+
+// flatten раскладывает заказ в строки по товарам; заказ без товаров даёт одну строку.
+func flatten(ord order.Order) [][]string {
+	base := []string{
+		ord.OrderUID, ord.TrackNumber, ord.Entry, ord.Locale, ord.InternalSignature,
+		ord.CustomerID, ord.DeliveryService, ord.ShardKey, strconv.Itoa(ord.SmID),
+		ord.DateCreated.UTC().Format(time.RFC3339), ord.OofShard,
+	}
+	if d := ord.Delivery; d != nil {
+		base = append(base, d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email)
+	} else {
+		base = append(base, make([]string, 7)...)
+	}
+	if p := ord.Payment; p != nil {
+		base = append(base, p.Transaction, p.RequestID, p.Currency, p.Provider,
+			p.Amount.Decimal(), strconv.Itoa(p.PaymentDT), p.Bank,
+			p.DeliveryCost.Decimal(), p.GoodsTotal.Decimal(), p.CustomFee.Decimal())
+	} else {
+		base = append(base, make([]string, 10)...)
+	}
+	if len(ord.Items) == 0 {
+		return [][]string{append(base, make([]string, 11)...)}
+	}
+	rows := make([][]string, 0, len(ord.Items))
+	for _, i := range ord.Items {
+		row := append([]string(nil), base...)
+		row = append(row, strconv.Itoa(i.ChrtID), i.TrackNumber, i.Price.Decimal(), i.Rid,
+			i.Name, strconv.Itoa(i.Sale), i.Size, i.TotalPrice.Decimal(),
+			strconv.Itoa(i.NmID), i.Brand, strconv.Itoa(i.Status))
+		rows = append(rows, row)
+	}
+	return rows
+}
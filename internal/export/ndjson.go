@@ -0,0 +1,26 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"task1/internal/order"
+)
+
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (w *ndjsonWriter) Write(ord order.Order) error {
+	return w.enc.Encode(ord)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.buf.Flush()
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"task1/internal/order"
+)
+
+// xlsxWriter пишет минимальную книгу из одного листа прямо в zip-поток:
+// строки с inline-строками, без общей таблицы строк, поэтому память не растёт.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	started bool
+	row     int
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="orders" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+	xlsxSheetHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetTail = `</sheetData></worksheet>`
+)
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{zip: zip.NewWriter(w)}
+}
+
+func (w *xlsxWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := w.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+	// лист пишется последним и остаётся открытым до Close
+	f, err := w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(f)
+	if _, err := w.sheet.WriteString(xlsxSheetHead); err != nil {
+		return err
+	}
+	return w.writeRow(header, false)
+}
+
+func (w *xlsxWriter) Write(ord order.Order) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	for _, row := range flatten(ord) {
+		if err := w.writeRow(row, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *xlsxWriter) writeRow(cells []string, typed bool) error {
+	w.row++
+	ref := strconv.Itoa(w.row)
+	w.sheet.WriteString(`<row r="` + ref + `">`)
+	for i, value := range cells {
+		cell := columnName(i) + ref
+		if typed && numericColumns[i] && value != "" {
+			w.sheet.WriteString(`<c r="` + cell + `"><v>` + value + `</v></c>`)
+			continue
+		}
+		w.sheet.WriteString(`<c r="` + cell + `" t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(w.sheet, []byte(value)); err != nil {
+			return err
+		}
+		w.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if _, err := w.sheet.WriteString(xlsxSheetTail); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// columnName переводит индекс колонки с нуля в буквенное имя: 0 → A, 26 → AA.
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,186 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"task1/internal/order"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// listSizes — сколько элементов ожидать от списочного поля без явного limit.
+var listSizes = map[string]int{
+	"searchOrders": order.DefaultSearchLimit,
+	"items":        10,
+}
+
+const maxFragmentDepth = 32
+
+// complexity оценивает стоимость запроса: каждое поле стоит 1,
+// а стоимость вложенных полей списка умножается на его ожидаемый размер.
+// Подсчёт останавливается, как только стоимость превысила limit: тогда
+// возвращается limit+1. Стоимость фрагмента считается один раз на запрос,
+// циклы фрагментов — ошибка.
+func complexity(query, operationName string, variables map[string]any, limit int) (int, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, err
+	}
+	var op *ast.OperationDefinition
+	for _, candidate := range doc.Operations {
+		if operationName == "" || candidate.Name == operationName {
+			op = candidate
+			break
+		}
+	}
+	if op == nil {
+		return 0, fmt.Errorf("operation %q not found", operationName)
+	}
+	c := &costCounter{
+		fragments: doc.Fragments,
+		variables: variables,
+		limit:     max(limit, 0),
+		costs:     make(map[string]fragmentCost),
+		visiting:  make(map[string]bool),
+	}
+	cost, _, err := c.selectionSet(op.SelectionSet, 0)
+	return cost, err
+}
+
+type costCounter struct {
+	fragments ast.FragmentDefinitionList
+	variables map[string]any
+	limit     int
+	// costs — посчитанные фрагменты, visiting — фрагменты на текущем пути
+	costs    map[string]fragmentCost
+	visiting map[string]bool
+}
+
+// fragmentCost — стоимость фрагмента и на сколько уровней он углубляет запрос.
+type fragmentCost struct {
+	cost, height int
+}
+
+// selectionSet возвращает стоимость набора полей, не больше limit+1, и его
+// высоту — на сколько уровней ниже depth спускается подсчёт.
+func (c *costCounter) selectionSet(set ast.SelectionSet, depth int) (cost, height int, err error) {
+	if depth > maxFragmentDepth {
+		return 0, 0, errors.New("query is nested too deeply")
+	}
+	for _, sel := range set {
+		var selCost, selHeight int
+		switch s := sel.(type) {
+		case *ast.Field:
+			selCost, selHeight, err = c.field(s, depth)
+		case *ast.InlineFragment:
+			selCost, selHeight, err = c.selectionSet(s.SelectionSet, depth+1)
+			selHeight++
+		case *ast.FragmentSpread:
+			selCost, selHeight, err = c.fragment(s.Name, depth)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		cost = c.add(cost, selCost)
+		height = max(height, selHeight)
+		if cost > c.limit {
+			return cost, height, nil
+		}
+	}
+	return cost, height, nil
+}
+
+func (c *costCounter) fragment(name string, depth int) (int, int, error) {
+	if c.visiting[name] {
+		return 0, 0, fmt.Errorf("fragment %q spreads itself", name)
+	}
+	if cached, ok := c.costs[name]; ok {
+		if depth+cached.height > maxFragmentDepth {
+			return 0, 0, errors.New("query is nested too deeply")
+		}
+		return cached.cost, cached.height, nil
+	}
+	fragment := c.fragments.ForName(name)
+	if fragment == nil {
+		return 0, 0, fmt.Errorf("fragment %q not found", name)
+	}
+	c.visiting[name] = true
+	cost, height, err := c.selectionSet(fragment.SelectionSet, depth+1)
+	delete(c.visiting, name)
+	if err != nil {
+		return 0, 0, err
+	}
+	c.costs[name] = fragmentCost{cost: cost, height: height + 1}
+	return cost, height + 1, nil
+}
+
+func (c *costCounter) field(f *ast.Field, depth int) (int, int, error) {
+	if f.Name == "__typename" {
+		return 0, 0, nil
+	}
+	children, height, err := c.selectionSet(f.SelectionSet, depth+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return c.add(1, c.mul(c.listSize(f), children)), height + 1, nil
+}
+
+// add и mul не дают стоимости превысить limit+1 и переполниться.
+func (c *costCounter) add(a, b int) int {
+	return min(a+b, c.limit+1)
+}
+
+func (c *costCounter) mul(a, b int) int {
+	if b != 0 && a > c.limit/b {
+		return c.limit + 1
+	}
+	return min(a*b, c.limit+1)
+}
+
+func (c *costCounter) listSize(f *ast.Field) int {
+	if arg := f.Arguments.ForName("limit"); arg != nil {
+		if n, ok := c.intValue(arg.Value); ok {
+			return max(n, 1)
+		}
+	}
+	if arg := f.Arguments.ForName("ids"); arg != nil {
+		if n, ok := c.listLen(arg.Value); ok {
+			return max(n, 1)
+		}
+		return maxOrdersPerQuery
+	}
+	if n, ok := listSizes[f.Name]; ok {
+		return n
+	}
+	return 1
+}
+
+func (c *costCounter) intValue(v *ast.Value) (int, bool) {
+	switch v.Kind {
+	case ast.IntValue:
+		n, err := strconv.Atoi(v.Raw)
+		return n, err == nil
+	case ast.Variable:
+		switch n := c.variables[v.Raw].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func (c *costCounter) listLen(v *ast.Value) (int, bool) {
+	switch v.Kind {
+	case ast.ListValue:
+		return len(v.Children), true
+	case ast.Variable:
+		if list, ok := c.variables[v.Raw].([]any); ok {
+			return len(list), true
+		}
+	}
+	return 0, false
+}
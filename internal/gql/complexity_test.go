@@ -0,0 +1,67 @@
+package gql
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fanOutQuery строит запрос из levels фрагментов, каждый из которых дважды
+// разворачивает следующий: без кэша подсчёт растёт как 2^levels.
+func fanOutQuery(levels int) string {
+	var b strings.Builder
+	b.WriteString("{ searchOrders(limit: 1) { ...F0 } }\n")
+	for i := range levels {
+		fmt.Fprintf(&b, "fragment F%d on Order { ...F%d ...F%d }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "fragment F%d on Order { order_uid }\n", levels)
+	return b.String()
+}
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		limit   int
+		want    int
+		wantErr bool
+	}{
+		{"поле", `{ order(id: "x") { order_uid } }`, 1000, 2, false},
+		{"список по limit", `{ searchOrders(limit: 5) { order_uid items { name } } }`, 1000, 1 + 5*(1+1+10), false},
+		{"фрагмент дважды", `{ order(id: "x") { ...A ...A } } fragment A on Order { order_uid }`, 1000, 3, false},
+		{"веер фрагментов", fanOutQuery(3), 1000, 1 + 8, false},
+		{"веер больше лимита", fanOutQuery(28), 1000, 1001, false},
+		{"огромный limit", `{ searchOrders(limit: 9223372036854775807) { order_uid items { name } } }`, 1000, 1001, false},
+		{"веер глубже лимита", fanOutQuery(maxFragmentDepth - 2), 1 << 40, 0, true},
+		{"цикл фрагментов", `{ order(id: "x") { ...A } } fragment A on Order { ...B } fragment B on Order { ...A }`, 1000, 0, true},
+		{"неизвестный фрагмент", `{ order(id: "x") { ...A } }`, 1000, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := complexity(tt.query, "", nil, tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: complexity = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComplexityFanOutIsFast(t *testing.T) {
+	// самый глубокий допустимый веер: 2^29 разворотов без кэша
+	levels := maxFragmentDepth - 3
+	query := fanOutQuery(levels)
+	start := time.Now()
+	cost, err := complexity(query, "", nil, 1<<40)
+	if err != nil {
+		t.Fatalf("complexity: %v", err)
+	}
+	if want := 1 + 1<<levels; cost != want {
+		t.Fatalf("complexity = %d, want %d", cost, want)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("подсчёт занял %s", elapsed)
+	}
+}
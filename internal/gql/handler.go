@@ -0,0 +1,97 @@
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"task1/internal/auth"
+	"task1/internal/cache"
+	"task1/internal/order"
+	"task1/internal/redact"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+type Handler struct {
+	schema        *graphql.Schema
+	cache         *cache.OrderCache
+	repo          order.Repository
+	maxComplexity int
+	logger        *slog.Logger
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func NewHandler(cache *cache.OrderCache, logger *slog.Logger, repo order.Repository, authMiddleware *auth.Middleware, redactor *redact.Redactor, maxDepth, maxComplexity int) (*Handler, error) {
+	root := &rootResolver{repo: repo, auth: authMiddleware, redactor: redactor}
+	schema, err := graphql.ParseSchema(schemaSDL, root,
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(16),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{schema: schema, cache: cache, repo: repo, maxComplexity: maxComplexity, logger: logger}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-type", "application/json")
+
+	// запрос, сложность которого не посчитать, не выполняется: иначе лимит
+	// обходится любым запросом, который не разбирает парсер сложности
+	cost, err := complexity(req.Query, req.OperationName, req.Variables, h.maxComplexity)
+	if err != nil {
+		h.logger.InfoContext(r.Context(), "Не удалось оценить сложность запроса GraphQL", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]any{{
+			"message": "cannot compute query complexity: " + err.Error(),
+		}}})
+		return
+	}
+	if cost > h.maxComplexity {
+		h.logger.InfoContext(r.Context(), "Запрос GraphQL отклонён по сложности", "complexity", cost)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]any{{
+			"message":    "query is too complex",
+			"extensions": map[string]any{"complexity": cost, "max_complexity": h.maxComplexity},
+		}}})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), loaderKey{}, newOrderLoader(h.cache, h.repo))
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	if len(resp.Errors) > 0 {
+		h.logger.InfoContext(ctx, "Запрос GraphQL завершился с ошибками", "errors", len(resp.Errors))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
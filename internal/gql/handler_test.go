@@ -0,0 +1,89 @@
+package gql
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"task1/internal/cache"
+	"task1/internal/config"
+	"task1/internal/money"
+	"task1/internal/order"
+	"task1/internal/order/memory"
+	"task1/internal/redact"
+)
+
+func TestHandlerComplexityLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	redactor, err := redact.New(config.Redaction{})
+	if err != nil {
+		t.Fatalf("redact.New: %v", err)
+	}
+	h, err := NewHandler(cache.NewOrderCache(logger), logger, memory.NewRepository(), nil, redactor, 6, 60)
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"простой запрос", `{ searchOrders(limit: 1) { order_uid } }`, http.StatusOK},
+		{"слишком сложный", `{ searchOrders(limit: 100) { order_uid items { name } } }`, http.StatusUnprocessableEntity},
+		{"веер фрагментов", fanOutQuery(28), http.StatusUnprocessableEntity},
+		{"цикл фрагментов", `{ order(id: "x") { ...A } } fragment A on Order { ...A }`, http.StatusBadRequest},
+		{"не разбирается", `{ searchOrders(limit: 1) { order_uid `, http.StatusBadRequest},
+		{"неизвестная операция", `query A { searchOrders { order_uid } }`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		target := "/graphql?query=" + url.QueryEscape(tt.query)
+		if tt.name == "неизвестная операция" {
+			target += "&operationName=B"
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: статус %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+func TestMoneyFields(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.NewRepository()
+	ord := order.Order{
+		TrackNumber: "TRACK", Entry: "WBIL", Locale: "en", CustomerID: "test", DeliveryService: "meest",
+		ShardKey: "9", SmID: 99, DateCreated: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), OofShard: "1",
+		Delivery: &order.Delivery{Name: "Test", Phone: "+9720000000", Zip: "2639809", City: "City", Address: "Street", Region: "Region", Email: "t@example.com"},
+		Payment: &order.Payment{Transaction: "tx", Currency: "USD", Provider: "wbpay",
+			Amount: money.New(181799, "USD"), PaymentDT: 1637907727, Bank: "alpha",
+			DeliveryCost: money.New(150000, "USD"), GoodsTotal: money.New(31799, "USD"), CustomFee: money.New(0, "USD")},
+		Items: []*order.Item{{ChrtID: 1, TrackNumber: "TRACK", Price: money.New(31799, "USD"), Rid: "rid", Name: "Item",
+			Size: "0", TotalPrice: money.New(31799, "USD"), NmID: 1, Brand: "Brand", Status: 202}},
+	}
+	id, err := repo.Save(context.Background(), ord)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	redactor, err := redact.New(config.Redaction{})
+	if err != nil {
+		t.Fatalf("redact.New: %v", err)
+	}
+	h, err := NewHandler(cache.NewOrderCache(logger), logger, repo, nil, redactor, 6, 1000)
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	query := `{ order(id: "` + id + `") { payment { amount amount_minor currency_exponent } items { total_price total_price_minor } } }`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(query), nil))
+	want := `{"data":{"order":{"payment":{"amount":1817,"amount_minor":"181799","currency_exponent":2},"items":[{"total_price":317,"total_price_minor":"31799"}]}}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Fatalf("ответ %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"task1/internal/cache"
+	"task1/internal/order"
+	"time"
+)
+
+const batchWait = 2 * time.Millisecond
+
+type loadResult struct {
+	order order.Order
+	found bool
+	err   error
+}
+
+type batch struct {
+	ids     []string
+	done    chan struct{}
+	results map[string]loadResult
+}
+
+// orderLoader собирает id, запрошенные резолверами в пределах одного запроса,
+// и загружает промахи кеша одним FindByIDs вместо N отдельных FindById.
+type orderLoader struct {
+	cache   *cache.OrderCache
+	repo    order.Repository
+	mu      sync.Mutex
+	pending *batch
+}
+
+func newOrderLoader(cache *cache.OrderCache, repo order.Repository) *orderLoader {
+	return &orderLoader{cache: cache, repo: repo}
+}
+
+func (l *orderLoader) Load(ctx context.Context, id string) (order.Order, bool, error) {
+	if ord, ok := l.cache.Load(id); ok {
+		return ord, true, nil
+	}
+	l.mu.Lock()
+	b := l.pending
+	if b == nil {
+		b = &batch{done: make(chan struct{})}
+		l.pending = b
+		time.AfterFunc(batchWait, func() { l.flush(ctx, b) })
+	}
+	b.ids = append(b.ids, id)
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return order.Order{}, false, ctx.Err()
+	}
+	res := b.results[id]
+	return res.order, res.found, res.err
+}
+
+func (l *orderLoader) flush(ctx context.Context, b *batch) {
+	l.mu.Lock()
+	if l.pending == b {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+
+	b.results = make(map[string]loadResult, len(b.ids))
+	orders, err := l.repo.FindByIDs(ctx, b.ids)
+	for _, id := range b.ids {
+		b.results[id] = loadResult{err: err}
+	}
+	for _, ord := range orders {
+		b.results[ord.OrderUID] = loadResult{order: ord, found: true}
+	}
+	close(b.done)
+}
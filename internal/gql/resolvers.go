@@ -0,0 +1,241 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"task1/internal/auth"
+	"task1/internal/money"
+	"task1/internal/order"
+	"task1/internal/redact"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const maxOrdersPerQuery = 100
+
+var errStorage = errors.New("order storage is unavailable")
+
+type loaderKey struct{}
+
+type rootResolver struct {
+	repo     order.Repository
+	auth     *auth.Middleware
+	redactor *redact.Redactor
+}
+
+func (r *rootResolver) loader(ctx context.Context) *orderLoader {
+	return ctx.Value(loaderKey{}).(*orderLoader)
+}
+
+func (r *rootResolver) visible(ctx context.Context, ord order.Order) *orderResolver {
+	if !r.auth.HasPermission(ctx, r.redactor.UnmaskPermission()) {
+		ord = redact.Redact(r.redactor, ord)
+	}
+	return &orderResolver{o: ord}
+}
+
+func (r *rootResolver) Order(ctx context.Context, args struct{ ID graphql.ID }) (*orderResolver, error) {
+	ord, found, err := r.loader(ctx).Load(ctx, string(args.ID))
+	if err != nil {
+		return nil, errStorage
+	}
+	if !found {
+		return nil, nil
+	}
+	return r.visible(ctx, ord), nil
+}
+
+func (r *rootResolver) Orders(ctx context.Context, args struct{ IDs []graphql.ID }) ([]*orderResolver, error) {
+	if len(args.IDs) > maxOrdersPerQuery {
+		return nil, fmt.Errorf("at most %d ids per query", maxOrdersPerQuery)
+	}
+	loader := r.loader(ctx)
+	type result struct {
+		ord   order.Order
+		found bool
+		err   error
+	}
+	results := make([]result, len(args.IDs))
+	done := make(chan struct{}, len(args.IDs))
+	for i, id := range args.IDs {
+		go func() {
+			ord, found, err := loader.Load(ctx, string(id))
+			results[i] = result{ord, found, err}
+			done <- struct{}{}
+		}()
+	}
+	for range args.IDs {
+		<-done
+	}
+	out := make([]*orderResolver, len(args.IDs))
+	for i, res := range results {
+		if res.err != nil {
+			return nil, errStorage
+		}
+		if res.found {
+			out[i] = r.visible(ctx, res.ord)
+		}
+	}
+	return out, nil
+}
+
+type orderFilter struct {
+	Customer_ID      *string
+	Delivery_Service *string
+	Track_Number     *string
+	Created_From     *string
+	Created_To       *string
+}
+
+func (r *rootResolver) SearchOrders(ctx context.Context, args struct {
+	Filter *orderFilter
+	Limit  int32
+	Offset int32
+}) ([]*orderResolver, error) {
+	filter := order.SearchFilter{Limit: int(args.Limit), Offset: int(args.Offset)}
+	if f := args.Filter; f != nil {
+		filter.CustomerID = deref(f.Customer_ID)
+		filter.DeliveryService = deref(f.Delivery_Service)
+		filter.TrackNumber = deref(f.Track_Number)
+		var err error
+		if filter.CreatedFrom, err = parseTime(f.Created_From); err != nil {
+			return nil, fmt.Errorf("created_from: %w", err)
+		}
+		if filter.CreatedTo, err = parseTime(f.Created_To); err != nil {
+			return nil, fmt.Errorf("created_to: %w", err)
+		}
+	}
+	orders, err := r.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, errStorage
+	}
+	out := make([]*orderResolver, 0, len(orders))
+	for _, ord := range orders {
+		out = append(out, r.visible(ctx, ord))
+	}
+	return out, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func parseTime(s *string) (time.Time, error) {
+	if s == nil || *s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, *s)
+}
+
+type orderResolver struct{ o order.Order }
+
+func (r *orderResolver) Order_UID() graphql.ID       { return graphql.ID(r.o.OrderUID) }
+func (r *orderResolver) Track_Number() string        { return r.o.TrackNumber }
+func (r *orderResolver) Entry() string               { return r.o.Entry }
+func (r *orderResolver) Locale() string              { return r.o.Locale }
+func (r *orderResolver) Internal_Signature() string  { return r.o.InternalSignature }
+func (r *orderResolver) Customer_ID() string         { return r.o.CustomerID }
+func (r *orderResolver) Delivery_Service() string    { return r.o.DeliveryService }
+func (r *orderResolver) Shardkey() string            { return r.o.ShardKey }
+func (r *orderResolver) Sm_ID() int32                { return int32(r.o.SmID) }
+func (r *orderResolver) Date_Created() string        { return r.o.DateCreated.Format(time.RFC3339) }
+func (r *orderResolver) Oof_Shard() string           { return r.o.OofShard }
+func (r *orderResolver) Status() string              { return string(r.o.Status) }
+func (r *orderResolver) Delivery() *deliveryResolver { return wrapDelivery(r.o.Delivery) }
+func (r *orderResolver) Payment() *paymentResolver   { return wrapPayment(r.o.Payment) }
+
+func (r *orderResolver) Items(args struct{ Limit *int32 }) []*itemResolver {
+	items := r.o.Items
+	if args.Limit != nil && int(*args.Limit) >= 0 && int(*args.Limit) < len(items) {
+		items = items[:*args.Limit]
+	}
+	out := make([]*itemResolver, 0, len(items))
+	for _, i := range items {
+		out = append(out, &itemResolver{i})
+	}
+	return out
+}
+
+type deliveryResolver struct{ d *order.Delivery }
+
+func wrapDelivery(d *order.Delivery) *deliveryResolver {
+	if d == nil {
+		return nil
+	}
+	return &deliveryResolver{d}
+}
+
+func (r *deliveryResolver) Delivery_ID() graphql.ID { return graphql.ID(r.d.DeliveryID) }
+func (r *deliveryResolver) Name() string            { return r.d.Name }
+func (r *deliveryResolver) Phone() string           { return r.d.Phone }
+func (r *deliveryResolver) Zip() string             { return r.d.Zip }
+func (r *deliveryResolver) City() string            { return r.d.City }
+func (r *deliveryResolver) Address() string         { return r.d.Address }
+func (r *deliveryResolver) Region() string          { return r.d.Region }
+func (r *deliveryResolver) Email() string           { return r.d.Email }
+
+type paymentResolver struct{ p *order.Payment }
+
+func wrapPayment(p *order.Payment) *paymentResolver {
+	if p == nil {
+		return nil
+	}
+	return &paymentResolver{p}
+}
+
+func (r *paymentResolver) Payment_ID() graphql.ID { return graphql.ID(r.p.PaymentID) }
+func (r *paymentResolver) Transaction() string    { return r.p.Transaction }
+func (r *paymentResolver) Request_ID() string     { return r.p.RequestID }
+func (r *paymentResolver) Currency() string       { return r.p.Currency }
+func (r *paymentResolver) Provider() string       { return r.p.Provider }
+func (r *paymentResolver) Amount() int32          { return int32(r.p.Amount.Major()) }
+func (r *paymentResolver) Payment_DT() int32      { return int32(r.p.PaymentDT) }
+func (r *paymentResolver) Bank() string           { return r.p.Bank }
+func (r *paymentResolver) Delivery_Cost() int32   { return int32(r.p.DeliveryCost.Major()) }
+func (r *paymentResolver) Goods_Total() int32     { return int32(r.p.GoodsTotal.Major()) }
+func (r *paymentResolver) Custom_Fee() int32      { return int32(r.p.CustomFee.Major()) }
+
+func (r *paymentResolver) Currency_Exponent() int32 {
+	if exp, ok := money.Currency(r.p.Currency).Exponent(); ok {
+		return int32(exp)
+	}
+	return money.DefaultExponent
+}
+func (r *paymentResolver) Amount_Minor() (string, error)        { return minorUnits(r.p.Amount) }
+func (r *paymentResolver) Delivery_Cost_Minor() (string, error) { return minorUnits(r.p.DeliveryCost) }
+func (r *paymentResolver) Goods_Total_Minor() (string, error)   { return minorUnits(r.p.GoodsTotal) }
+func (r *paymentResolver) Custom_Fee_Minor() (string, error)    { return minorUnits(r.p.CustomFee) }
+
+type itemResolver struct{ i *order.Item }
+
+func (r *itemResolver) Item_ID() graphql.ID  { return graphql.ID(r.i.ItemID) }
+func (r *itemResolver) Chrt_ID() int32       { return int32(r.i.ChrtID) }
+func (r *itemResolver) Track_Number() string { return r.i.TrackNumber }
+func (r *itemResolver) Price() int32         { return int32(r.i.Price.Major()) }
+func (r *itemResolver) Rid() string          { return r.i.Rid }
+func (r *itemResolver) Name() string         { return r.i.Name }
+func (r *itemResolver) Sale() int32          { return int32(r.i.Sale) }
+func (r *itemResolver) Size() string         { return r.i.Size }
+func (r *itemResolver) Total_Price() int32   { return int32(r.i.TotalPrice.Major()) }
+func (r *itemResolver) Nm_ID() int32         { return int32(r.i.NmID) }
+func (r *itemResolver) Brand() string        { return r.i.Brand }
+func (r *itemResolver) Status() int32        { return int32(r.i.Status) }
+
+func (r *itemResolver) Price_Minor() (string, error)       { return minorUnits(r.i.Price) }
+func (r *itemResolver) Total_Price_Minor() (string, error) { return minorUnits(r.i.TotalPrice) }
+
+// minorUnits — точная сумма в минимальных единицах валюты. Сумму, у которой
+// знаков после запятой больше, чем допускает валюта, отдать точно нельзя.
+func minorUnits(m money.Money) (string, error) {
+	v, err := m.Minor()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(v, 10), nil
+}
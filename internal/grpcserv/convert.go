@@ -0,0 +1,115 @@
+package grpcserv
+
+import (
+	"task1/internal/money"
+	"task1/internal/order"
+	"task1/internal/stream"
+	"task1/pkg/api/orderv1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProto(o order.Order) *orderv1.Order {
+	pb := &orderv1.Order{
+		OrderUid:          o.OrderUID,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		Shardkey:          o.ShardKey,
+		SmId:              int64(o.SmID),
+		DateCreated:       timestamppb.New(o.DateCreated),
+		OofShard:          o.OofShard,
+		Status:            string(o.Status),
+	}
+	if d := o.Delivery; d != nil {
+		pb.Delivery = &orderv1.Delivery{
+			DeliveryId: d.DeliveryID,
+			Name:       d.Name,
+			Phone:      d.Phone,
+			Zip:        d.Zip,
+			City:       d.City,
+			Address:    d.Address,
+			Region:     d.Region,
+			Email:      d.Email,
+		}
+	}
+	if p := o.Payment; p != nil {
+		pb.Payment = &orderv1.Payment{
+			PaymentId:         p.PaymentID,
+			Transaction:       p.Transaction,
+			RequestId:         p.RequestID,
+			Currency:          p.Currency,
+			Provider:          p.Provider,
+			Amount:            p.Amount.Major(),
+			PaymentDt:         int64(p.PaymentDT),
+			Bank:              p.Bank,
+			DeliveryCost:      p.DeliveryCost.Major(),
+			GoodsTotal:        p.GoodsTotal.Major(),
+			CustomFee:         p.CustomFee.Major(),
+			CurrencyExponent:  currencyExponent(p.Currency),
+			AmountMinor:       minorUnits(p.Amount),
+			DeliveryCostMinor: minorUnits(p.DeliveryCost),
+			GoodsTotalMinor:   minorUnits(p.GoodsTotal),
+			CustomFeeMinor:    minorUnits(p.CustomFee),
+		}
+	}
+	pb.Items = make([]*orderv1.Item, 0, len(o.Items))
+	for _, i := range o.Items {
+		pb.Items = append(pb.Items, &orderv1.Item{
+			ItemId:          i.ItemID,
+			ChrtId:          int64(i.ChrtID),
+			TrackNumber:     i.TrackNumber,
+			Price:           i.Price.Major(),
+			Rid:             i.Rid,
+			Name:            i.Name,
+			Sale:            int64(i.Sale),
+			Size:            i.Size,
+			TotalPrice:      i.TotalPrice.Major(),
+			NmId:            int64(i.NmID),
+			Brand:           i.Brand,
+			Status:          int64(i.Status),
+			PriceMinor:      minorUnits(i.Price),
+			TotalPriceMinor: minorUnits(i.TotalPrice),
+		})
+	}
+	return pb
+}
+
+func eventToProto(e stream.Event) *orderv1.OrderEvent {
+	s := e.Summary
+	return &orderv1.OrderEvent{
+		Id: e.ID,
+		Order: &orderv1.OrderSummary{
+			OrderUid:         s.OrderUID,
+			TrackNumber:      s.TrackNumber,
+			CustomerId:       s.CustomerID,
+			DeliveryService:  s.DeliveryService,
+			Amount:           s.Amount.Major(),
+			Currency:         s.Currency,
+			ItemsCount:       int32(s.ItemsCount),
+			DateCreated:      timestamppb.New(s.DateCreated),
+			CurrencyExponent: currencyExponent(s.Currency),
+			AmountMinor:      minorUnits(s.Amount),
+		},
+	}
+}
+
+func currencyExponent(code string) uint32 {
+	if exp, ok := money.Currency(code).Exponent(); ok {
+		return uint32(exp)
+	}
+	return money.DefaultExponent
+}
+
+// minorUnits отдаёт 0, если сумма не выражается в минимальных единицах валюты
+// (в бд больше знаков после запятой, чем допускает валюта).
+func minorUnits(m money.Money) int64 {
+	v, err := m.Minor()
+	if err != nil {
+		return 0
+	}
+	return v
+}
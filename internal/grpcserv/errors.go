@@ -0,0 +1,38 @@
+package grpcserv
+
+import (
+	"errors"
+	"task1/internal/order"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus переводит ошибки order.Err* в gRPC-статусы; детали драйвера наружу не уходят.
+func toStatus(err error) error {
+	var verr *order.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		st := status.New(codes.InvalidArgument, order.ErrValidation.Error())
+		details := &errdetails.BadRequest{}
+		for _, f := range verr.Fields {
+			details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Rule,
+			})
+		}
+		if withDetails, detailsErr := st.WithDetails(details); detailsErr == nil {
+			st = withDetails
+		}
+		return st.Err()
+	case errors.Is(err, order.ErrNotFound):
+		return status.Error(codes.NotFound, order.ErrNotFound.Error())
+	case errors.Is(err, order.ErrConflict):
+		return status.Error(codes.Aborted, order.ErrConflict.Error())
+	case errors.Is(err, order.ErrUnavailable):
+		return status.Error(codes.Unavailable, order.ErrUnavailable.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
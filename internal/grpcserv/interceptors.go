@@ -0,0 +1,124 @@
+package grpcserv
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"task1/internal/auth"
+	"task1/internal/logging"
+	"task1/internal/metrics"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func metricsUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+func metricsStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observe(info.FullMethod, start, err)
+	return err
+}
+
+func observe(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	metrics.GRPCRequests.WithLabelValues(method, code).Inc()
+	metrics.GRPCDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
+
+func (s *Server) loggingUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = withRequestID(ctx)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (s *Server) loggingStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := withRequestID(ss.Context())
+	start := time.Now()
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	s.logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func (s *Server) logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []any{"method", method, "code", code.String(), "duration", time.Since(start)}
+	if code == codes.OK || code == codes.NotFound || code == codes.InvalidArgument {
+		s.logger.InfoContext(ctx, "gRPC вызов", attrs...)
+		return
+	}
+	s.logger.ErrorContext(ctx, "gRPC вызов завершился ошибкой", append(attrs, "error", err)...)
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-request-id"); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if id == "" || len(id) > 128 {
+		id = logging.NewRequestID()
+	}
+	return logging.WithAttrs(ctx, "request_id", id)
+}
+
+func (s *Server) authUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) authStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorize переиспользует HTTP-аутентификацию: метаданные превращаются в заголовки,
+// а маршрутом для ролей служит полное имя метода, например /order.v1.OrderService/GetOrder.
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	if s.auth == nil || s.auth.IsPublic(method) {
+		return ctx, nil
+	}
+	header := http.Header{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			for _, v := range values {
+				header.Add(key, v)
+			}
+		}
+	}
+	principal, err := s.auth.Authorize(ctx, header, method)
+	if errors.Is(err, auth.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,219 @@
+package grpcserv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"task1/internal/auth"
+	"task1/internal/cache"
+	"task1/internal/order"
+	"task1/internal/redact"
+	"task1/internal/stream"
+	"task1/pkg/api/orderv1"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type Server struct {
+	orderv1.UnimplementedOrderServiceServer
+	cache    *cache.OrderCache
+	repo     order.Repository
+	hub      *stream.Hub
+	auth     *auth.Middleware
+	redactor *redact.Redactor
+	logger   *slog.Logger
+	port     int
+	// batchMaxIDs — максимум order_uid в BatchGetOrders, общий с HTTP batchGet
+	batchMaxIDs int
+	grpcServer  *grpc.Server
+	health      *health.Server
+}
+
+func NewServer(cache *cache.OrderCache, logger *slog.Logger, repo order.Repository, hub *stream.Hub, port int, batchMaxIDs int, authMiddleware *auth.Middleware, redactor *redact.Redactor) *Server {
+	s := &Server{
+		cache:       cache,
+		repo:        repo,
+		hub:         hub,
+		auth:        authMiddleware,
+		redactor:    redactor,
+		logger:      logger,
+		port:        port,
+		batchMaxIDs: batchMaxIDs,
+		health:      health.NewServer(),
+	}
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(metricsUnary, s.loggingUnary, s.authUnary),
+		grpc.ChainStreamInterceptor(metricsStream, s.loggingStream, s.authStream),
+	)
+	orderv1.RegisterOrderServiceServer(s.grpcServer, s)
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	reflection.Register(s.grpcServer)
+	return s
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		s.logger.Error("Ошибка запуска gRPC сервера", "error", err)
+		return err
+	}
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	s.health.SetServingStatus(orderv1.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	if err := s.grpcServer.Serve(listener); err != nil {
+		s.logger.Error("Ошибка работы gRPC сервера", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) {
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+	s.logger.Info("gRPC сервер завершил работу")
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error) {
+	if req.GetOrderUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_uid is required")
+	}
+	ord, found, err := s.lookup(ctx, req.GetOrderUid())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !found {
+		return nil, toStatus(order.ErrNotFound)
+	}
+	return &orderv1.GetOrderResponse{Order: s.visible(ctx, ord)}, nil
+}
+
+// BatchGetOrders берёт заказы из кэша, а промахи читает из бд одним запросом.
+func (s *Server) BatchGetOrders(ctx context.Context, req *orderv1.BatchGetOrdersRequest) (*orderv1.BatchGetOrdersResponse, error) {
+	if len(req.GetOrderUids()) > s.batchMaxIDs {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d order_uids per request", s.batchMaxIDs)
+	}
+	resp := &orderv1.BatchGetOrdersResponse{Orders: make(map[string]*orderv1.Order)}
+	seen := make(map[string]bool, len(req.GetOrderUids()))
+	misses := make([]string, 0, len(req.GetOrderUids()))
+	requested := make(map[string]string, len(req.GetOrderUids()))
+	for _, id := range req.GetOrderUids() {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if ord, ok := s.cache.Load(id); ok {
+			resp.Orders[id] = s.visible(ctx, ord)
+			continue
+		}
+		if parsed, err := uuid.Parse(id); err == nil {
+			misses = append(misses, id)
+			requested[parsed.String()] = id
+		}
+	}
+	if len(misses) > 0 {
+		orders, err := s.repo.FindByIDs(ctx, misses)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Ошибка пакетного чтения заказов из бд", "error", err, "count", len(misses))
+			return nil, toStatus(err)
+		}
+		for _, ord := range orders {
+			if id, ok := requested[ord.OrderUID]; ok {
+				resp.Orders[id] = s.visible(ctx, ord)
+			}
+		}
+	}
+	for _, id := range req.GetOrderUids() {
+		if _, found := resp.Orders[id]; !found && seen[id] {
+			resp.NotFound = append(resp.NotFound, id)
+			delete(seen, id)
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) SearchOrders(ctx context.Context, req *orderv1.SearchOrdersRequest) (*orderv1.SearchOrdersResponse, error) {
+	filter := order.SearchFilter{
+		CustomerID:      req.GetCustomerId(),
+		DeliveryService: req.GetDeliveryService(),
+		TrackNumber:     req.GetTrackNumber(),
+		Limit:           int(req.GetLimit()),
+		Offset:          int(req.GetOffset()),
+	}
+	if req.CreatedFrom != nil {
+		filter.CreatedFrom = req.GetCreatedFrom().AsTime()
+	}
+	if req.CreatedTo != nil {
+		filter.CreatedTo = req.GetCreatedTo().AsTime()
+	}
+	orders, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Ошибка поиска заказов в бд", "error", err)
+		return nil, toStatus(err)
+	}
+	resp := &orderv1.SearchOrdersResponse{Orders: make([]*orderv1.Order, 0, len(orders))}
+	for _, ord := range orders {
+		resp.Orders = append(resp.Orders, s.visible(ctx, ord))
+	}
+	return resp, nil
+}
+
+func (s *Server) WatchOrders(req *orderv1.WatchOrdersRequest, srv orderv1.OrderService_WatchOrdersServer) error {
+	filter := stream.Filter{DeliveryService: req.GetDeliveryService(), CustomerID: req.GetCustomerId()}
+	sub, backlog := s.hub.Subscribe(filter, req.GetLastEventId())
+	defer s.hub.Unsubscribe(sub)
+	for _, event := range backlog {
+		if err := srv.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case <-sub.Dropped():
+			return status.Error(codes.ResourceExhausted, "client is too slow, resume with last_event_id")
+		case event := <-sub.Events():
+			if err := srv.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) lookup(ctx context.Context, id string) (order.Order, bool, error) {
+	if ord, ok := s.cache.Load(id); ok {
+		return ord, true, nil
+	}
+	ord, err := s.repo.FindById(ctx, id)
+	if errors.Is(err, order.ErrNotFound) {
+		return ord, false, nil
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Ошибка поиска заказа в бд", "error", err)
+		return ord, false, err
+	}
+	return ord, true, nil
+}
+
+func (s *Server) visible(ctx context.Context, ord order.Order) *orderv1.Order {
+	if !s.auth.HasPermission(ctx, s.redactor.UnmaskPermission()) {
+		ord = redact.Redact(s.redactor, ord)
+	}
+	return toProto(ord)
+}
@@ -0,0 +1,100 @@
+package grpcserv
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"task1/internal/cache"
+	"task1/internal/config"
+	"task1/internal/money"
+	"task1/internal/order"
+	"task1/internal/order/memory"
+	"task1/internal/redact"
+	"task1/pkg/api/orderv1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// countingRepository считает обращения к бд за заказами.
+type countingRepository struct {
+	order.Repository
+	findByID, findByIDs int
+}
+
+func (r *countingRepository) FindById(ctx context.Context, id string) (order.Order, error) {
+	r.findByID++
+	return r.Repository.FindById(ctx, id)
+}
+
+func (r *countingRepository) FindByIDs(ctx context.Context, ids []string) ([]order.Order, error) {
+	r.findByIDs++
+	return r.Repository.FindByIDs(ctx, ids)
+}
+
+func testOrder(track string) order.Order {
+	return order.Order{
+		TrackNumber: track, Entry: "WBIL", Locale: "en", CustomerID: "test", DeliveryService: "meest",
+		ShardKey: "9", SmID: 99, DateCreated: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), OofShard: "1",
+		Delivery: &order.Delivery{Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com"},
+		Payment: &order.Payment{Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay",
+			Amount: money.New(181700, "USD"), PaymentDT: 1637907727, Bank: "alpha",
+			DeliveryCost: money.New(150000, "USD"), GoodsTotal: money.New(31700, "USD"), CustomFee: money.New(0, "USD")},
+		Items: []*order.Item{{ChrtID: 9934930, TrackNumber: track, Price: money.New(31700, "USD"), Rid: "ab4219087a764ae0btest",
+			Name: "Mascaras", Size: "0", TotalPrice: money.New(31700, "USD"), NmID: 2389212, Brand: "Vivienne Sabo", Status: 202}},
+	}
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &countingRepository{Repository: memory.NewRepository()}
+	orderCache := cache.NewOrderCache(logger)
+	var ids []string
+	for _, track := range []string{"TRACK1", "TRACK2", "TRACK3"} {
+		id, err := repo.Save(ctx, testOrder(track))
+		if err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	cached, _ := repo.Repository.FindById(ctx, ids[0])
+	orderCache.Store(cached)
+	redactor, err := redact.New(config.Redaction{})
+	if err != nil {
+		t.Fatalf("redact.New: %v", err)
+	}
+	s := NewServer(orderCache, logger, repo, nil, 0, 4, nil, redactor)
+
+	missing := "00000000-0000-0000-0000-000000000000"
+	resp, err := s.BatchGetOrders(ctx, &orderv1.BatchGetOrdersRequest{
+		OrderUids: []string{ids[0], ids[1], ids[2], ids[1]},
+	})
+	if err != nil {
+		t.Fatalf("BatchGetOrders: %v", err)
+	}
+	if len(resp.GetOrders()) != 3 || len(resp.GetNotFound()) != 0 {
+		t.Fatalf("получено %d заказов, не найдены %v", len(resp.GetOrders()), resp.GetNotFound())
+	}
+	if repo.findByID != 0 || repo.findByIDs != 1 {
+		t.Fatalf("FindById вызван %d раз, FindByIDs %d раз; ожидался один FindByIDs", repo.findByID, repo.findByIDs)
+	}
+
+	resp, err = s.BatchGetOrders(ctx, &orderv1.BatchGetOrdersRequest{OrderUids: []string{missing, "not-a-uuid", ids[0], missing}})
+	if err != nil {
+		t.Fatalf("BatchGetOrders: %v", err)
+	}
+	if want := []string{missing, "not-a-uuid"}; !slices.Equal(resp.GetNotFound(), want) {
+		t.Fatalf("not_found = %v, want %v", resp.GetNotFound(), want)
+	}
+
+	_, err = s.BatchGetOrders(ctx, &orderv1.BatchGetOrdersRequest{OrderUids: []string{ids[0], ids[1], ids[2], missing, missing}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("запрос больше лимита: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task1/internal/cache"
+	"task1/pkg/client"
+	"task1/pkg/migr"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func Postgres(db client.CLient) Check {
+	return func(ctx context.Context) error {
+		return db.Ping(ctx)
+	}
+}
+
+func Kafka(brokers []string) Check {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, broker := range brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			conn.Close()
+			return nil
+		}
+		return fmt.Errorf("нет доступных брокеров: %w", errors.Join(errs...))
+	}
+}
+
+func CacheWarm(c *cache.OrderCache) Check {
+	return func(ctx context.Context) error {
+		if !c.Warmed() {
+			return errors.New("кеш ещё не восстановлен из бд")
+		}
+		return nil
+	}
+}
+
+func Migrations(m *migr.Migrator, path string) Check {
+	return func(ctx context.Context) error {
+		return m.CheckVersion(ctx, path)
+	}
+}
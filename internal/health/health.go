@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type checkResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type report struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// Checker отдаёт /healthz (процесс жив) и /readyz (все зависимости готовы).
+type Checker struct {
+	checks  []namedCheck
+	timeout time.Duration
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, report{Status: "ok"})
+}
+
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+	results := make(map[string]checkResult, len(c.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			start := time.Now()
+			res := checkResult{Status: "ok"}
+			if err := nc.check(ctx); err != nil {
+				res.Status = "fail"
+				res.Error = err.Error()
+			}
+			res.DurationMs = time.Since(start).Milliseconds()
+			mu.Lock()
+			results[nc.name] = res
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+	rep := report{Status: "ok", Checks: results}
+	status := http.StatusOK
+	for _, res := range results {
+		if res.Status != "ok" {
+			rep.Status = "fail"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeReport(w, status, rep)
+}
+
+func writeReport(w http.ResponseWriter, status int, rep report) {
+	w.Header().Set("Content-type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type attrsKey struct{}
+
+// WithAttrs добавляет в контекст атрибуты, которые попадут в каждую строку лога,
+// записанную с этим контекстом (request_id, order_uid, kafka_offset и т.п.).
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	attrs := make([]slog.Attr, len(existing), len(existing)+len(args)/2)
+	copy(attrs, existing)
+	r := slog.NewRecord(zeroTime, 0, "", 0)
+	r.Add(args...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// RequestIDFromContext возвращает request_id, который RequestID положил в контекст.
+func RequestIDFromContext(ctx context.Context) string {
+	for _, a := range attrsFromContext(ctx) {
+		if a.Key == "request_id" {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var zeroTime time.Time
+
+// contextHandler фильтрует записи по уровню компонента и дописывает
+// атрибуты из контекста и trace_id/span_id текущего спана.
+type contextHandler struct {
+	inner slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(attrsFromContext(ctx)...)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{inner: h.inner.WithAttrs(attrs), level: h.level}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{inner: h.inner.WithGroup(name), level: h.level}
+}
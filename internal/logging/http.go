@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID берёт X-Request-ID из запроса или генерирует новый
+// и кладёт его в контекст для логов и в заголовок ответа.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithAttrs(r.Context(), "request_id", id)))
+	})
+}
+
+func NewRequestID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type levelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+// LevelHandler: GET возвращает текущие уровни, PUT меняет уровень компонента.
+func (l *Logging) LevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		if err := l.SetLevel(req.Component, req.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		l.Logger().InfoContext(r.Context(), "Изменён уровень логирования", "target", req.Component, "level", req.Level)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(l.Levels())
+}
@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"task1/internal/config"
+)
+
+// Logging создаёт логгеры компонентов поверх одного обработчика.
+// Уровни хранятся в LevelVar, поэтому их можно менять на лету.
+type Logging struct {
+	inner        slog.Handler
+	defaultLevel *slog.LevelVar
+	mu           sync.Mutex
+	levels       map[string]*slog.LevelVar
+	explicit     map[string]bool
+}
+
+func New(cfg config.Logging, out io.Writer, replaceAttr func([]string, slog.Attr) slog.Attr) (*Logging, error) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug - 4, ReplaceAttr: replaceAttr}
+	var inner slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		inner = slog.NewTextHandler(out, opts)
+	case "json":
+		inner = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("неизвестный формат логов %q", cfg.Format)
+	}
+	l := &Logging{inner: inner, defaultLevel: new(slog.LevelVar), levels: make(map[string]*slog.LevelVar), explicit: make(map[string]bool)}
+	if err := l.SetLevel("", cfg.Level); err != nil {
+		return nil, err
+	}
+	for component, level := range cfg.Components {
+		if err := l.SetLevel(component, level); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Logger возвращает корневой логгер, у которого уровень по умолчанию.
+func (l *Logging) Logger() *slog.Logger {
+	return slog.New(&contextHandler{inner: l.inner, level: l.defaultLevel})
+}
+
+func (l *Logging) Component(name string) *slog.Logger {
+	return slog.New(&contextHandler{inner: l.inner, level: l.levelVar(name)}).With("component", name)
+}
+
+// SetLevel меняет уровень компонента; пустое имя означает уровень по умолчанию,
+// который наследуют компоненты без явно заданного уровня.
+func (l *Logging) SetLevel(component, level string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	if component == "" || component == "default" {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.defaultLevel.Set(parsed)
+		for name, lv := range l.levels {
+			if !l.explicit[name] {
+				lv.Set(parsed)
+			}
+		}
+		return nil
+	}
+	lv := l.levelVar(component)
+	l.mu.Lock()
+	l.explicit[component] = true
+	l.mu.Unlock()
+	lv.Set(parsed)
+	return nil
+}
+
+func (l *Logging) Levels() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	levels := map[string]string{"default": l.defaultLevel.Level().String()}
+	names := make([]string, 0, len(l.levels))
+	for name := range l.levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		levels[name] = l.levels[name].Level().String()
+	}
+	return levels
+}
+
+func (l *Logging) levelVar(component string) *slog.LevelVar {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lv, ok := l.levels[component]
+	if !ok {
+		lv = new(slog.LevelVar)
+		lv.Set(l.defaultLevel.Level())
+		l.levels[component] = lv
+	}
+	return lv
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"errors"
+	"task1/internal/order"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "orders"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Количество HTTP-запросов по маршруту, методу и статусу.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Время обработки HTTP-запроса.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Количество gRPC-вызовов по методу и коду ответа.",
+	}, []string{"method", "code"})
+
+	GRPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Время обработки gRPC-вызова.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	CacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Количество попаданий в кеш заказов.",
+	})
+
+	CacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Количество промахов кеша заказов.",
+	})
+
+	CacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "size",
+		Help:      "Количество заказов в кеше.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Время выполнения запросов репозитория.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"query", "status"})
+
+	KafkaMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "messages_total",
+		Help:      "Сообщения, прочитанные консьюмером, по результату обработки.",
+	}, []string{"result"})
+
+	StatusEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "status_events_total",
+		Help:      "События статусов по результату обработки.",
+	}, []string{"result"})
+
+	RuleViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rules",
+		Name:      "violations_total",
+		Help:      "Нарушения бизнес-правил в принятых на проверку заказах.",
+	}, []string{"rule", "severity"})
+
+	RetentionPartitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retention",
+		Name:      "partitions_total",
+		Help:      "Секции заказов, обработанные задачей хранения, по результату.",
+	}, []string{"result"})
+)
+
+// ObserveViolations учитывает нарушения из отчёта проверки заказа.
+func ObserveViolations(violations []order.Violation) {
+	for _, v := range violations {
+		RuleViolations.WithLabelValues(v.Rule, string(v.Severity)).Inc()
+	}
+}
+
+func ObserveQuery(query string, start time.Time, err error) {
+	status := "ok"
+	switch {
+	case errors.Is(err, order.ErrNotFound):
+		status = "not_found"
+	case err != nil:
+		status = "error"
+	}
+	DBQueryDuration.WithLabelValues(query, status).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolCollector struct {
+	pool          *pgxpool.Pool
+	acquired      *prometheus.Desc
+	idle          *prometheus.Desc
+	total         *prometheus.Desc
+	max           *prometheus.Desc
+	acquireCount  *prometheus.Desc
+	emptyAcquire  *prometheus.Desc
+	acquireWait   *prometheus.Desc
+	canceledCount *prometheus.Desc
+}
+
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+	prometheus.MustRegister(&poolCollector{
+		pool:          pool,
+		acquired:      desc("acquired_conns", "Соединения, взятые из пула."),
+		idle:          desc("idle_conns", "Свободные соединения в пуле."),
+		total:         desc("total_conns", "Все соединения пула."),
+		max:           desc("max_conns", "Максимальный размер пула."),
+		acquireCount:  desc("acquire_total", "Сколько раз соединение бралось из пула."),
+		emptyAcquire:  desc("empty_acquire_total", "Сколько раз пришлось ждать свободное соединение."),
+		acquireWait:   desc("acquire_duration_seconds_total", "Суммарное время ожидания соединения."),
+		canceledCount: desc("canceled_acquire_total", "Сколько ожиданий соединения отменено контекстом."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquireCount
+	ch <- c.emptyAcquire
+	ch <- c.acquireWait
+	ch <- c.canceledCount
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"task1/internal/order"
+	"time"
+)
+
+// InstrumentedRepository замеряет время запросов к обёрнутому репозиторию.
+type InstrumentedRepository struct {
+	order.Repository
+}
+
+func NewInstrumentedRepository(repo order.Repository) order.Repository {
+	return &InstrumentedRepository{Repository: repo}
+}
+
+func (r *InstrumentedRepository) Save(ctx context.Context, ord order.Order) (string, error) {
+	start := time.Now()
+	id, err := r.Repository.Save(ctx, ord)
+	ObserveQuery("Save", start, err)
+	return id, err
+}
+
+func (r *InstrumentedRepository) SaveBatch(ctx context.Context, orders []order.Order) ([]string, error) {
+	start := time.Now()
+	ids, err := r.Repository.SaveBatch(ctx, orders)
+	ObserveQuery("SaveBatch", start, err)
+	return ids, err
+}
+
+func (r *InstrumentedRepository) FindAll(ctx context.Context) ([]order.Order, error) {
+	start := time.Now()
+	orders, err := r.Repository.FindAll(ctx)
+	ObserveQuery("FindAll", start, err)
+	return orders, err
+}
+
+func (r *InstrumentedRepository) FindById(ctx context.Context, id string) (order.Order, error) {
+	start := time.Now()
+	ord, err := r.Repository.FindById(ctx, id)
+	ObserveQuery("FindById", start, err)
+	return ord, err
+}
+
+func (r *InstrumentedRepository) Search(ctx context.Context, filter order.SearchFilter) ([]order.Order, error) {
+	start := time.Now()
+	orders, err := r.Repository.Search(ctx, filter)
+	ObserveQuery("Search", start, err)
+	return orders, err
+}
+
+func (r *InstrumentedRepository) FindByIDs(ctx context.Context, ids []string) ([]order.Order, error) {
+	start := time.Now()
+	orders, err := r.Repository.FindByIDs(ctx, ids)
+	ObserveQuery("FindByIDs", start, err)
+	return orders, err
+}
+
+func (r *InstrumentedRepository) Stream(ctx context.Context, filter order.SearchFilter, fn func(order.Order) error) error {
+	start := time.Now()
+	err := r.Repository.Stream(ctx, filter, fn)
+	ObserveQuery("Stream", start, err)
+	return err
+}
+
+func (r *InstrumentedRepository) Transition(ctx context.Context, id string, to order.Status, reason string) (order.StatusChange, error) {
+	start := time.Now()
+	change, err := r.Repository.Transition(ctx, id, to, reason)
+	ObserveQuery("Transition", start, err)
+	return change, err
+}
+
+func (r *InstrumentedRepository) StatusHistory(ctx context.Context, id string) ([]order.StatusChange, error) {
+	start := time.Now()
+	history, err := r.Repository.StatusHistory(ctx, id)
+	ObserveQuery("StatusHistory", start, err)
+	return history, err
+}
+
+func (r *InstrumentedRepository) ApplyEvent(ctx context.Context, event order.StatusEvent) (order.EventResult, error) {
+	start := time.Now()
+	result, err := r.Repository.ApplyEvent(ctx, event)
+	ObserveQuery("ApplyEvent", start, err)
+	return result, err
+}
+
+func (r *InstrumentedRepository) Checkpoints(ctx context.Context, id string) ([]order.Checkpoint, error) {
+	start := time.Now()
+	checkpoints, err := r.Repository.Checkpoints(ctx, id)
+	ObserveQuery("Checkpoints", start, err)
+	return checkpoints, err
+}
+
+func (r *InstrumentedRepository) Audit(ctx context.Context, id string) ([]order.AuditEntry, error) {
+	start := time.Now()
+	entries, err := r.Repository.Audit(ctx, id)
+	ObserveQuery("Audit", start, err)
+	return entries, err
+}
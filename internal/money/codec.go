@@ -0,0 +1,69 @@
+package money
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MarshalJSON пишет сумму JSON-числом в основных единицах, как и раньше
+// писались int-поля: 1817, 18.17.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON принимает число или строку с числом. Валюту сумма получает
+// позже, через WithCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+	parsed, err := Parse(string(bytes.Trim(data, `"`)), "")
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// NumericValue кодирует сумму в NUMERIC для pgx.
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(m.amount), Exp: -int32(m.exp), Valid: true}, nil
+}
+
+// ScanNumeric читает NUMERIC из pgx; NULL читается как ноль. Валюту
+// задаёт вызывающий через WithCurrency.
+func (m *Money) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		*m = Money{}
+		return nil
+	}
+	if v.NaN || v.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: NUMERIC не является конечным числом", ErrSyntax)
+	}
+	value := new(big.Int).Set(v.Int)
+	exp := v.Exp
+	ten := big.NewInt(10)
+	remainder := new(big.Int)
+	for exp < 0 && value.Sign() != 0 {
+		quotient, rem := new(big.Int).QuoRem(value, ten, remainder)
+		if rem.Sign() != 0 {
+			break
+		}
+		value, exp = quotient, exp+1
+	}
+	if value.Sign() == 0 {
+		exp = 0
+	}
+	for ; exp > 0; exp-- {
+		value.Mul(value, ten)
+	}
+	if -exp > maxExp || !value.IsInt64() {
+		return ErrOverflow
+	}
+	*m = Money{amount: value.Int64(), exp: uint8(-exp)}
+	return nil
+}
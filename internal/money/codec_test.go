@@ -0,0 +1,109 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(1817, "USD"), `18.17`},
+		{New(1800, "USD"), `18`},
+		{New(5, "USD"), `0.05`},
+		{New(-5, "USD"), `-0.05`},
+		{New(1817, "JPY"), `1817`},
+		{New(1500, "BHD"), `1.5`},
+		{Money{}, `0`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.m)
+		if err != nil || string(data) != tt.want {
+			t.Errorf("Marshal(%s) = %s, %v, want %s", tt.m, data, err, tt.want)
+			continue
+		}
+		var back Money
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Errorf("Unmarshal(%s): %v", data, err)
+			continue
+		}
+		back, err = back.WithCurrency(tt.m.Currency())
+		if err != nil || !back.Equal(tt.m) {
+			t.Errorf("round-trip %s = %s, %v", tt.m, back, err)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{`18.17`, "18.17", nil},
+		{`"18.17"`, "18.17", nil},
+		{`1.817e1`, "18.17", nil},
+		{`null`, "0", nil},
+		{`"x"`, "", ErrSyntax},
+		{`1e400`, "", ErrSyntax},
+		{`100000000000000000000`, "", ErrOverflow},
+	}
+	for _, tt := range tests {
+		var m Money
+		err := json.Unmarshal([]byte(tt.in), &m)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Unmarshal(%s): err = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && m.Decimal() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, m.Decimal(), tt.want)
+		}
+	}
+}
+
+func TestNumeric(t *testing.T) {
+	v, err := New(1817, "USD").NumericValue()
+	if err != nil || v.Int.Int64() != 1817 || v.Exp != -2 || !v.Valid {
+		t.Fatalf("NumericValue = %+v, %v", v, err)
+	}
+	huge := new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1))
+	tests := []struct {
+		name     string
+		in       pgtype.Numeric
+		currency Currency
+		want     string
+		err      error
+	}{
+		{"как записано", v, "USD", "18.17 USD", nil},
+		{"лишние нули после запятой", pgtype.Numeric{Int: big.NewInt(1817000), Exp: -5, Valid: true}, "USD", "18.17 USD", nil},
+		{"лишние знаки после запятой", pgtype.Numeric{Int: big.NewInt(18175), Exp: -3, Valid: true}, "USD", "", ErrPrecision},
+		{"положительный показатель", pgtype.Numeric{Int: big.NewInt(5), Exp: 2, Valid: true}, "JPY", "500 JPY", nil},
+		{"ноль с масштабом", pgtype.Numeric{Int: big.NewInt(0), Exp: -30, Valid: true}, "USD", "0.00 USD", nil},
+		{"NULL", pgtype.Numeric{}, "USD", "0.00 USD", nil},
+		{"NaN", pgtype.Numeric{NaN: true, Valid: true}, "USD", "", ErrSyntax},
+		{"бесконечность", pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true}, "USD", "", ErrSyntax},
+		{"слишком много знаков", pgtype.Numeric{Int: big.NewInt(1), Exp: -19, Valid: true}, "USD", "", ErrOverflow},
+		{"больше int64", pgtype.Numeric{Int: huge, Exp: 0, Valid: true}, "USD", "", ErrOverflow},
+	}
+	for _, tt := range tests {
+		var m Money
+		err := m.ScanNumeric(tt.in)
+		if err == nil {
+			m, err = m.WithCurrency(tt.currency)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && m.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, m, tt.want)
+		}
+	}
+}
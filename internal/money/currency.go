@@ -0,0 +1,53 @@
+package money
+
+import "strings"
+
+// Currency — трёхбуквенный код ISO 4217.
+type Currency string
+
+// DefaultExponent используется для валют, которых нет в таблице.
+const DefaultExponent = 2
+
+// exponents — число знаков после запятой у минимальной единицы валюты
+// по ISO 4217. Валюты с показателем 2 перечислены в twoDigit.
+var exponents = map[Currency]uint8{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+var twoDigit = strings.Fields(`
+	AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD
+	BTN BWP BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP
+	ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR
+	JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU
+	MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR
+	RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS
+	TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD YER ZAR ZMW ZWL`)
+
+func init() {
+	for _, code := range twoDigit {
+		exponents[Currency(code)] = 2
+	}
+}
+
+// Exponent возвращает число знаков минимальной единицы; ok=false для
+// неизвестного кода.
+func (c Currency) Exponent() (exp uint8, ok bool) {
+	exp, ok = exponents[c]
+	return exp, ok
+}
+
+// Known сообщает, есть ли код в таблице ISO 4217.
+func (c Currency) Known() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+func (c Currency) exponent() uint8 {
+	if exp, ok := exponents[c]; ok {
+		return exp
+	}
+	return DefaultExponent
+}
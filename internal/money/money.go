@@ -0,0 +1,272 @@
+// Package money хранит денежные суммы точно, в минимальных единицах валюты.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrOverflow         = errors.New("money: сумма не помещается в int64")
+	ErrPrecision        = errors.New("money: больше знаков после запятой, чем допускает валюта")
+	ErrCurrencyMismatch = errors.New("money: разные валюты")
+	ErrSyntax           = errors.New("money: некорректная сумма")
+)
+
+// maxExp ограничивает масштаб: 10^18 ещё помещается в int64.
+const maxExp = 18
+
+// Money — точная сумма amount·10^-exp в валюте currency.
+//
+// Пока валюта не задана (например, сразу после разбора JSON), exp равен
+// числу знаков во входных данных; WithCurrency приводит его к показателю
+// валюты. Нулевое значение — ноль без валюты.
+type Money struct {
+	amount   int64
+	exp      uint8
+	currency Currency
+}
+
+// New создаёт сумму из минимальных единиц валюты: New(1817, "USD") — 18.17 USD.
+func New(minor int64, currency Currency) Money {
+	return Money{amount: minor, exp: currency.exponent(), currency: currency}
+}
+
+// Parse разбирает десятичную запись в основных единицах: Parse("18.17", "USD").
+func Parse(s string, currency Currency) (Money, error) {
+	amount, exp, err := parseDecimal(s)
+	if err != nil {
+		return Money{}, err
+	}
+	m := Money{amount: amount, exp: exp}
+	if currency == "" {
+		return m, nil
+	}
+	return m.WithCurrency(currency)
+}
+
+func (m Money) Currency() Currency { return m.currency }
+
+func (m Money) IsZero() bool { return m.amount == 0 }
+
+func (m Money) Sign() int {
+	switch {
+	case m.amount > 0:
+		return 1
+	case m.amount < 0:
+		return -1
+	}
+	return 0
+}
+
+// Minor возвращает сумму в минимальных единицах её валюты.
+func (m Money) Minor() (int64, error) {
+	return rescale(m.amount, m.exp, m.currency.exponent())
+}
+
+// Major возвращает целую часть суммы в основных единицах, дробь отбрасывается.
+func (m Money) Major() int64 {
+	return m.amount / pow10(m.exp)
+}
+
+// WithCurrency задаёт валюту и приводит масштаб к её показателю. Если
+// дробная часть длиннее, чем допускает валюта, возвращается ErrPrecision.
+func (m Money) WithCurrency(currency Currency) (Money, error) {
+	target := m.exp
+	if exp, ok := currency.Exponent(); ok {
+		target = exp
+	}
+	amount, err := rescale(m.amount, m.exp, target)
+	if err != nil {
+		return m, err
+	}
+	return Money{amount: amount, exp: target, currency: currency}, nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	a, b, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	if (b.amount > 0 && a.amount > math.MaxInt64-b.amount) || (b.amount < 0 && a.amount < math.MinInt64-b.amount) {
+		return Money{}, ErrOverflow
+	}
+	a.amount += b.amount
+	return a, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	o.amount = -o.amount
+	return m.Add(o)
+}
+
+// Mul умножает сумму на целое число, например цену на количество.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.amount == 0 || n == 0 {
+		return Money{exp: m.exp, currency: m.currency}, nil
+	}
+	product := m.amount * n
+	if product/n != m.amount || (m.amount == -1 && n == math.MinInt64) || (n == -1 && m.amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	m.amount = product
+	return m, nil
+}
+
+// Cmp сравнивает суммы одной валюты: -1, 0 или 1.
+func (m Money) Cmp(o Money) (int, error) {
+	diff, err := m.Sub(o)
+	if err != nil {
+		return 0, err
+	}
+	return diff.Sign(), nil
+}
+
+// Equal сравнивает значения с учётом масштаба: 18.1 и 18.10 равны.
+func (m Money) Equal(o Money) bool {
+	c, err := m.Cmp(o)
+	return err == nil && c == 0
+}
+
+// Decimal возвращает кратчайшую точную десятичную запись: 1817, 18.1, 0.05.
+func (m Money) Decimal() string {
+	s := m.fixed()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// String форматирует сумму с показателем валюты: "18.10 USD".
+func (m Money) String() string {
+	s := m.fixed()
+	if m.currency != "" {
+		if exp, ok := m.currency.Exponent(); ok && exp > m.exp {
+			if scaled, err := rescale(m.amount, m.exp, exp); err == nil {
+				s = Money{amount: scaled, exp: exp}.fixed()
+			}
+		}
+		s += " " + string(m.currency)
+	}
+	return s
+}
+
+func (m Money) fixed() string {
+	digits := strconv.FormatUint(absUint(m.amount), 10)
+	if m.exp > 0 {
+		if pad := int(m.exp) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		cut := len(digits) - int(m.exp)
+		digits = digits[:cut] + "." + digits[cut:]
+	}
+	if m.amount < 0 {
+		digits = "-" + digits
+	}
+	return digits
+}
+
+// align приводит суммы к общему масштабу и проверяет совпадение валют;
+// сумма без валюты совместима с любой.
+func align(a, b Money) (Money, Money, error) {
+	switch {
+	case a.currency == "":
+		a.currency = b.currency
+	case b.currency == "":
+		b.currency = a.currency
+	case a.currency != b.currency:
+		return a, b, fmt.Errorf("%w: %s и %s", ErrCurrencyMismatch, a.currency, b.currency)
+	}
+	exp := max(a.exp, b.exp)
+	var err error
+	if a.amount, err = rescale(a.amount, a.exp, exp); err != nil {
+		return a, b, err
+	}
+	if b.amount, err = rescale(b.amount, b.exp, exp); err != nil {
+		return a, b, err
+	}
+	a.exp, b.exp = exp, exp
+	return a, b, nil
+}
+
+func rescale(amount int64, from, to uint8) (int64, error) {
+	switch {
+	case to == from:
+		return amount, nil
+	case to > from:
+		factor := pow10(to - from)
+		if amount > math.MaxInt64/factor || amount < math.MinInt64/factor {
+			return 0, ErrOverflow
+		}
+		return amount * factor, nil
+	default:
+		factor := pow10(from - to)
+		if amount%factor != 0 {
+			return 0, ErrPrecision
+		}
+		return amount / factor, nil
+	}
+}
+
+func parseDecimal(s string) (int64, uint8, error) {
+	s = strings.TrimSpace(s)
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	shift := 0
+	if hasExponent {
+		var err error
+		if shift, err = strconv.Atoi(exponent); err != nil || shift > maxExp || shift < -maxExp {
+			return 0, 0, fmt.Errorf("%w: %q", ErrSyntax, s)
+		}
+	}
+	negative := strings.HasPrefix(mantissa, "-")
+	whole, frac, hasDot := strings.Cut(strings.TrimPrefix(mantissa, "-"), ".")
+	if whole == "" || (hasDot && frac == "") ||
+		strings.Trim(whole, "0123456789") != "" || strings.Trim(frac, "0123456789") != "" {
+		return 0, 0, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+	// показатель степени сдвигает запятую: 1.5e2 → 150, 15e-1 → 1.5
+	digits := whole + frac
+	scale := len(frac) - shift
+	if scale < 0 {
+		digits += strings.Repeat("0", -scale)
+		scale = 0
+	}
+	// хвостовые нули дробной части не несут точности
+	for scale > 0 && strings.HasSuffix(digits, "0") {
+		digits = digits[:len(digits)-1]
+		scale--
+	}
+	if scale > maxExp {
+		return 0, 0, fmt.Errorf("%w: %q", ErrPrecision, s)
+	}
+	value, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || value > math.MaxInt64 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	amount := int64(value)
+	if negative {
+		amount = -amount
+	}
+	return amount, uint8(scale), nil
+}
+
+func pow10(n uint8) int64 {
+	p := int64(1)
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
@@ -0,0 +1,137 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency Currency
+		minor    int64
+		str      string
+		err      error
+	}{
+		{"18.17", "USD", 1817, "18.17 USD", nil},
+		{"18.1", "USD", 1810, "18.10 USD", nil},
+		{"18.170", "USD", 1817, "18.17 USD", nil},
+		{"-0.05", "USD", -5, "-0.05 USD", nil},
+		{"18", "JPY", 18, "18 JPY", nil},
+		{"18.5", "JPY", 0, "", ErrPrecision},
+		{"1.5", "BHD", 1500, "1.500 BHD", nil},
+		{"1.2345", "BHD", 0, "", ErrPrecision},
+		{"1.5e2", "USD", 15000, "150.00 USD", nil},
+		{"15e-1", "USD", 150, "1.50 USD", nil},
+		{" 7 ", "USD", 700, "7.00 USD", nil},
+		{"92233720368547758.07", "USD", math.MaxInt64, "92233720368547758.07 USD", nil},
+		{"92233720368547758.07", "BHD", 0, "", ErrOverflow},
+		{"9223372036854775808", "", 0, "", ErrOverflow},
+		{"0.0000000000000000001", "", 0, "", ErrPrecision},
+		{"", "USD", 0, "", ErrSyntax},
+		{"1.", "USD", 0, "", ErrSyntax},
+		{".5", "USD", 0, "", ErrSyntax},
+		{"1,5", "USD", 0, "", ErrSyntax},
+		{"1e99", "USD", 0, "", ErrSyntax},
+		{"abc", "USD", 0, "", ErrSyntax},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.in, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q, %s): err = %v, want %v", tt.in, tt.currency, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		minor, err := m.Minor()
+		if err != nil || minor != tt.minor {
+			t.Errorf("Parse(%q, %s).Minor() = %d, %v, want %d", tt.in, tt.currency, minor, err, tt.minor)
+		}
+		if got := m.String(); got != tt.str {
+			t.Errorf("Parse(%q, %s).String() = %q, want %q", tt.in, tt.currency, got, tt.str)
+		}
+	}
+}
+
+func TestWithCurrency(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency Currency
+		decimal  string
+		err      error
+	}{
+		{"18.17", "USD", "18.17", nil},
+		{"18", "KWD", "18", nil},
+		{"0.001", "KWD", "0.001", nil},
+		{"0.001", "USD", "", ErrPrecision},
+		{"0.0001", "CLF", "0.0001", nil},
+		// неизвестная валюта сохраняет масштаб входных данных
+		{"1.234", "XXX", "1.234", nil},
+	}
+	for _, tt := range tests {
+		parsed, err := Parse(tt.in, "")
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.in, err)
+		}
+		m, err := parsed.WithCurrency(tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s.WithCurrency(%s): err = %v, want %v", tt.in, tt.currency, err, tt.err)
+			continue
+		}
+		if err == nil && (m.Decimal() != tt.decimal || m.Currency() != tt.currency) {
+			t.Errorf("%s.WithCurrency(%s) = %s %s, want %s %s", tt.in, tt.currency, m.Decimal(), m.Currency(), tt.decimal, tt.currency)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	half, _ := Parse("0.5", "")
+	tests := []struct {
+		name string
+		op   func() (Money, error)
+		want string
+		err  error
+	}{
+		{"сложение", func() (Money, error) { return New(1817, "USD").Add(New(183, "USD")) }, "20.00 USD", nil},
+		{"сложение без валюты", func() (Money, error) { return New(50, "USD").Add(half) }, "1.00 USD", nil},
+		{"разные валюты", func() (Money, error) { return New(1, "USD").Add(New(1, "EUR")) }, "", ErrCurrencyMismatch},
+		{"переполнение сложения", func() (Money, error) { return New(math.MaxInt64, "USD").Add(New(1, "USD")) }, "", ErrOverflow},
+		{"переполнение вниз", func() (Money, error) { return New(math.MinInt64, "USD").Add(New(-1, "USD")) }, "", ErrOverflow},
+		{"переполнение при выравнивании", func() (Money, error) { return New(math.MaxInt64, "JPY").Add(half) }, "", ErrOverflow},
+		{"вычитание", func() (Money, error) { return New(1817, "USD").Sub(New(1817, "USD")) }, "0.00 USD", nil},
+		{"вычитание MinInt64", func() (Money, error) { return New(0, "USD").Sub(New(math.MinInt64, "USD")) }, "", ErrOverflow},
+		{"умножение", func() (Money, error) { return New(317, "USD").Mul(3) }, "9.51 USD", nil},
+		{"умножение на ноль", func() (Money, error) { return New(317, "USD").Mul(0) }, "0.00 USD", nil},
+		{"переполнение умножения", func() (Money, error) { return New(math.MaxInt64/2+1, "USD").Mul(2) }, "", ErrOverflow},
+		{"-1 × MinInt64", func() (Money, error) { return New(-1, "USD").Mul(math.MinInt64) }, "", ErrOverflow},
+		{"MinInt64 × -1", func() (Money, error) { return New(math.MinInt64, "USD").Mul(-1) }, "", ErrOverflow},
+	}
+	for _, tt := range tests {
+		got, err := tt.op()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tenth, _ := Parse("18.1", "")
+	if !New(1810, "USD").Equal(tenth) {
+		t.Error("18.10 USD и 18.1 должны быть равны")
+	}
+	if New(1, "USD").Equal(New(1, "EUR")) {
+		t.Error("суммы в разных валютах не равны")
+	}
+	if c, err := New(1, "USD").Cmp(New(2, "USD")); err != nil || c != -1 {
+		t.Errorf("Cmp = %d, %v, want -1", c, err)
+	}
+	if got := New(1899, "USD").Major(); got != 18 {
+		t.Errorf("Major = %d, want 18", got)
+	}
+}
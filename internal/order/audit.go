@@ -0,0 +1,106 @@
+package order
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Операции в журнале аудита.
+const (
+	AuditCreate = "create"
+	AuditStatus = "status"
+	AuditEvent  = "event"
+)
+
+// AuditEntry — запись журнала изменений заказа. Diff хранит только
+// изменившиеся поля по json-пути: "payment.bank", "items[<item_id>].status".
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	OrderUID  string                 `json:"order_uid"`
+	Operation string                 `json:"operation"`
+	Actor     string                 `json:"actor"`
+	RequestID string                 `json:"request_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
+	Diff      map[string]FieldChange `json:"diff"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// FieldChange — значение поля до и после изменения; nil — поля не было.
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff сравнивает два состояния заказа. before == nil — заказ создан.
+// Товары сопоставляются по item_id, а без него — по позиции.
+func Diff(before, after *Order) map[string]FieldChange {
+	b, a := flattenOrder(before), flattenOrder(after)
+	diff := make(map[string]FieldChange)
+	for path, value := range a {
+		if old, ok := b[path]; !ok || !reflect.DeepEqual(old, value) {
+			diff[path] = FieldChange{Before: old, After: value}
+		}
+	}
+	for path, old := range b {
+		if _, ok := a[path]; !ok {
+			diff[path] = FieldChange{Before: old}
+		}
+	}
+	return diff
+}
+
+func flattenOrder(o *Order) map[string]any {
+	flat := make(map[string]any)
+	if o == nil {
+		return flat
+	}
+	data, err := json.Marshal(o)
+	if err != nil {
+		return flat
+	}
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return flat
+	}
+	flatten(flat, "", doc)
+	return flat
+}
+
+func flatten(flat map[string]any, path string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if path == "" {
+				flatten(flat, key, nested)
+			} else {
+				flatten(flat, path+"."+key, nested)
+			}
+		}
+	case []any:
+		objects := len(v) > 0
+		for _, el := range v {
+			if _, ok := el.(map[string]any); !ok {
+				objects = false
+			}
+		}
+		// массивы значений, например tags, сравниваются целиком
+		if !objects {
+			flat[path] = v
+			return
+		}
+		for i, el := range v {
+			key := fmt.Sprint(i)
+			if id, ok := el.(map[string]any)["item_id"].(string); ok && id != "" {
+				key = id
+			}
+			flatten(flat, path+"["+key+"]", el)
+		}
+	default:
+		flat[path] = v
+	}
+}
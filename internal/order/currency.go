@@ -0,0 +1,49 @@
+package order
+
+import (
+	"encoding/json"
+	"task1/internal/money"
+)
+
+// BindCurrency задаёт всем суммам заказа валюту из Payment.Currency и
+// приводит их к её минимальным единицам. Вызывается после разбора JSON и
+// чтения из бд, где у отдельных полей валюты нет.
+func (o *Order) BindCurrency() error {
+	if o.Payment == nil || o.Payment.Currency == "" {
+		return nil
+	}
+	currency := money.Currency(o.Payment.Currency)
+	var fields []FieldError
+	bind := func(path string, m *money.Money) {
+		bound, err := m.WithCurrency(currency)
+		if err != nil {
+			fields = append(fields, FieldError{Field: path, Rule: "currency_precision"})
+			return
+		}
+		*m = bound
+	}
+	bind("payment.amount", &o.Payment.Amount)
+	bind("payment.delivery_cost", &o.Payment.DeliveryCost)
+	bind("payment.goods_total", &o.Payment.GoodsTotal)
+	bind("payment.custom_fee", &o.Payment.CustomFee)
+	for i, item := range o.Items {
+		if item == nil {
+			continue
+		}
+		bind(itemPath(i, "price"), &item.Price)
+		bind(itemPath(i, "total_price"), &item.TotalPrice)
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// UnmarshalJSON разбирает заказ и сразу привязывает суммы к валюте оплаты.
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type plain Order
+	if err := json.Unmarshal(data, (*plain)(o)); err != nil {
+		return err
+	}
+	return o.BindCurrency()
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"task1/internal/logging"
+	"task1/internal/order"
+	"task1/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// insertAudit пишет запись журнала в ту же транзакцию, что и само изменение:
+// откат изменения откатывает и запись. Пустой diff не пишется.
+func insertAudit(ctx context.Context, tx pgx.Tx, orderUID, operation string, diff map[string]order.FieldChange) (err error) {
+	if len(diff) == 0 {
+		return nil
+	}
+	spanCtx, span := tracer.Start(ctx, "INSERT order_audit")
+	defer func() { tracing.End(span, err) }()
+	encoded, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+	var traceID string
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		traceID = sc.TraceID().String()
+	}
+	_, err = tx.Exec(spanCtx,
+		`INSERT INTO order_audit (order_uid, operation, actor, request_id, trace_id, diff)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)`,
+		orderUID, operation, order.ActorFromContext(ctx), logging.RequestIDFromContext(ctx), traceID, encoded)
+	return err
+}
+
+// findByIdTx читает заказ внутри транзакции, чтобы увидеть её незакоммиченные изменения.
+func findByIdTx(ctx context.Context, tx pgx.Tx, id string) (order.Order, error) {
+	rows, err := tx.Query(ctx, `SELECT `+orderColumns+` `+orderJoins+` WHERE o.order_uid=$1`, id)
+	if err != nil {
+		return order.Order{}, err
+	}
+	defer rows.Close()
+	orders, err := collectOrders(rows)
+	if err != nil {
+		return order.Order{}, err
+	}
+	if len(orders) == 0 {
+		return order.Order{}, pgx.ErrNoRows
+	}
+	return orders[0], nil
+}
+
+func (r *Repository) Audit(ctx context.Context, id string) ([]order.AuditEntry, error) {
+	ctx, span := tracer.Start(ctx, "SELECT order audit", trace.WithAttributes(attribute.String("order_uid", id)))
+	defer span.End()
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: некорректный order_uid %q", order.ErrNotFound, id)
+	}
+	rows, err := r.client.Query(ctx,
+		`SELECT id, order_uid, operation, actor, COALESCE(request_id, ''), COALESCE(trace_id, ''), diff, created_at
+		FROM order_audit WHERE order_uid=$1 ORDER BY id`, id)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении журнала изменений", "error", err)
+		return nil, classify(err)
+	}
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (order.AuditEntry, error) {
+		var (
+			e    order.AuditEntry
+			diff []byte
+		)
+		if err := row.Scan(&e.ID, &e.OrderUID, &e.Operation, &e.Actor, &e.RequestID, &e.TraceID, &diff, &e.CreatedAt); err != nil {
+			return e, err
+		}
+		return e, json.Unmarshal(diff, &e.Diff)
+	})
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении журнала изменений", "error", err)
+		return nil, classify(err)
+	}
+	// запись о создании есть у каждого заказа, пустой журнал — нет заказа
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
+	}
+	return entries, nil
+}
@@ -0,0 +1,273 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"task1/internal/order"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// StorageMode — где хранится заказ целиком.
+type StorageMode string
+
+const (
+	// ModeRelational — только таблицы orders, delivery, payment, items.
+	ModeRelational StorageMode = "relational"
+	// ModeDual — таблицы и orders.doc в одной транзакции, чтение из doc.
+	ModeDual StorageMode = "dual"
+	// ModeDocument — только строка orders с doc, дочерние таблицы пустые.
+	// Вернуться из него в relational нельзя: заказов нет в таблицах.
+	ModeDocument StorageMode = "document"
+)
+
+func ParseStorageMode(s string) (StorageMode, error) {
+	switch m := StorageMode(strings.ToLower(s)); m {
+	case ModeRelational, ModeDual, ModeDocument:
+		return m, nil
+	case "":
+		return ModeRelational, nil
+	default:
+		return "", fmt.Errorf("неизвестный режим хранения %q", s)
+	}
+}
+
+// orderDocSQL собирает doc из строк таблиц для заказа с алиасом o. Тот же
+// запрос заполняет doc в миграции 7_order_doc, поэтому формат совпадает с
+// json-тегами order.Order.
+const orderDocSQL = `jsonb_build_object(
+		'order_uid', o.order_uid, 'track_number', o.track_number, 'entry', o.entry,
+		'locale', o.locale, 'internal_signature', o.internal_signature,
+		'customer_id', o.customer_id, 'delivery_service', o.delivery_service,
+		'shardkey', o.shardkey, 'sm_id', o.sm_id,
+		'date_created', to_char(o.date_created, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+		'oof_shard', o.oof_shard, 'status', o.status, 'tags', to_jsonb(o.tags),
+		'delivery', (SELECT to_jsonb(d) - 'order_uid' - 'date_created' FROM delivery d
+			WHERE d.order_uid = o.order_uid AND d.date_created = o.date_created LIMIT 1),
+		'payment', (SELECT to_jsonb(p) - 'order_uid' - 'date_created' - 'event_at' FROM payment p
+			WHERE p.order_uid = o.order_uid AND p.date_created = o.date_created LIMIT 1),
+		'items', COALESCE((SELECT jsonb_agg(to_jsonb(i) - 'order_uid' - 'date_created' - 'status_event_at' ORDER BY i.item_id)
+			FROM items i WHERE i.order_uid = o.order_uid AND i.date_created = o.date_created), '[]'::jsonb))`
+
+// docEventTimes — время последнего применённого события по товарам и оплате
+// в режиме document, где нет items.status_event_at и payment.event_at.
+type docEventTimes struct {
+	Items   map[string]time.Time `json:"items,omitempty"`
+	Payment *time.Time           `json:"payment,omitempty"`
+}
+
+// insertDoc пишет doc только что вставленной строки orders. В режиме document
+// идентификаторы доставки, оплаты и товаров выдаются здесь, а не бд.
+func (r *Repository) insertDoc(ctx context.Context, tx pgx.Tx, ord order.Order) (order.Order, error) {
+	ctx, span := tracer.Start(ctx, "UPDATE orders doc")
+	defer span.End()
+	if r.mode == ModeDual {
+		_, err := tx.Exec(ctx, `UPDATE orders o SET doc = `+orderDocSQL+` WHERE o.order_uid=$1`, ord.OrderUID)
+		return ord, err
+	}
+	if ord.Delivery != nil {
+		delivery := *ord.Delivery
+		delivery.DeliveryID = uuid.NewString()
+		ord.Delivery = &delivery
+	}
+	if ord.Payment != nil {
+		payment := *ord.Payment
+		payment.PaymentID = uuid.NewString()
+		ord.Payment = &payment
+	}
+	items := make([]*order.Item, 0, len(ord.Items))
+	for _, it := range ord.Items {
+		item := *it
+		item.ItemID = uuid.NewString()
+		items = append(items, &item)
+	}
+	ord.Items = items
+	if ord.Tags == nil {
+		ord.Tags = []string{}
+	}
+	doc, err := json.Marshal(ord)
+	if err != nil {
+		return ord, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE orders SET doc=$2 WHERE order_uid=$1`, ord.OrderUID, doc)
+	return ord, err
+}
+
+// syncDoc приводит doc в соответствие с таблицами после изменения заказа.
+// В режиме relational doc сбрасывается: после переключения на dual такой
+// заказ читается из таблиц, а не из устаревшего документа.
+func (r *Repository) syncDoc(ctx context.Context, tx pgx.Tx, id string) error {
+	switch r.mode {
+	case ModeDual:
+		_, err := tx.Exec(ctx, `UPDATE orders o SET doc = `+orderDocSQL+` WHERE o.order_uid=$1`, id)
+		return err
+	case ModeRelational:
+		_, err := tx.Exec(ctx, `UPDATE orders SET doc=NULL WHERE order_uid=$1 AND doc IS NOT NULL`, id)
+		return err
+	}
+	return nil
+}
+
+// loadTx читает заказ внутри транзакции из того места, откуда его читает
+// текущий режим.
+func (r *Repository) loadTx(ctx context.Context, tx pgx.Tx, id string) (order.Order, error) {
+	if r.mode == ModeRelational {
+		return findByIdTx(ctx, tx, id)
+	}
+	var doc []byte
+	if err := tx.QueryRow(ctx, `SELECT doc FROM orders WHERE order_uid=$1`, id).Scan(&doc); err != nil {
+		return order.Order{}, err
+	}
+	if doc == nil {
+		return findByIdTx(ctx, tx, id)
+	}
+	return decodeDoc(doc)
+}
+
+func decodeDoc(doc []byte) (order.Order, error) {
+	var o order.Order
+	if err := json.Unmarshal(doc, &o); err != nil {
+		return o, fmt.Errorf("разбор orders.doc: %w", err)
+	}
+	if o.Items == nil {
+		o.Items = []*order.Item{}
+	}
+	_ = o.BindCurrency()
+	return o, nil
+}
+
+// findDocs читает заказы из doc запросом, который возвращает order_uid и doc;
+// keys — как у reader. Заказы без doc, записанные в режиме relational,
+// дочитываются из таблиц того же сервера.
+func (r *Repository) findDocs(ctx context.Context, keys []string, query string, args ...any) ([]order.Order, error) {
+	reader := r.reader(ctx, keys...)
+	rows, err := reader.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		orders  []order.Order
+		missing = make(map[string]int)
+	)
+	for rows.Next() {
+		var (
+			id  string
+			doc []byte
+		)
+		if err := rows.Scan(&id, &doc); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if doc == nil {
+			missing[id] = len(orders)
+			orders = append(orders, order.Order{OrderUID: id})
+			continue
+		}
+		o, err := decodeDoc(doc)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return orders, nil
+	}
+	ids := make([]string, 0, len(missing))
+	for id := range missing {
+		ids = append(ids, id)
+	}
+	rows, err = reader.Query(ctx, `SELECT `+orderColumns+` `+orderJoins+` WHERE o.order_uid = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	relational, err := collectOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range relational {
+		orders[missing[o.OrderUID]] = o
+	}
+	return orders, nil
+}
+
+// applyDocEvent применяет товары и оплату события к doc в режиме document
+// с той же защитой от устаревших событий, что и в таблицах. Статус к этому
+// моменту уже записан в doc. false — у заказа нет doc, он записан до
+// переключения режима и обновляется в таблицах.
+func (r *Repository) applyDocEvent(ctx context.Context, tx pgx.Tx, ev order.StatusEvent, orderUID string, count func(bool)) (bool, error) {
+	var (
+		doc   []byte
+		times docEventTimes
+	)
+	err := tx.QueryRow(ctx, `SELECT doc, doc_event_at FROM orders WHERE order_uid=$1`, orderUID).Scan(&doc, &times)
+	if err != nil || doc == nil {
+		return false, err
+	}
+	ord, err := decodeDoc(doc)
+	if err != nil {
+		return false, err
+	}
+	if times.Items == nil {
+		times.Items = make(map[string]time.Time)
+	}
+	changed := false
+	for _, event := range ev.Items {
+		applied := false
+		for _, item := range ord.Items {
+			if (event.ChrtID == 0 || item.ChrtID != event.ChrtID) && (event.Rid == "" || item.Rid != event.Rid) {
+				continue
+			}
+			if last, ok := times.Items[item.ItemID]; ok && !last.Before(ev.EventTime) {
+				continue
+			}
+			item.Status = event.Status
+			times.Items[item.ItemID] = ev.EventTime
+			applied = true
+		}
+		changed = changed || applied
+		count(applied)
+	}
+	if p := ev.Payment; p != nil {
+		applied := ord.Payment != nil && (times.Payment == nil || times.Payment.Before(ev.EventTime))
+		if applied {
+			if p.Transaction != "" {
+				ord.Payment.Transaction = p.Transaction
+			}
+			if p.Provider != "" {
+				ord.Payment.Provider = p.Provider
+			}
+			if p.Bank != "" {
+				ord.Payment.Bank = p.Bank
+			}
+			if p.PaymentDT != 0 {
+				ord.Payment.PaymentDT = p.PaymentDT
+			}
+			eventTime := ev.EventTime
+			times.Payment = &eventTime
+		}
+		changed = changed || applied
+		count(applied)
+	}
+	if !changed {
+		return true, nil
+	}
+	if doc, err = json.Marshal(ord); err != nil {
+		return true, err
+	}
+	encodedTimes, err := json.Marshal(times)
+	if err != nil {
+		return true, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE orders SET doc=$2, doc_event_at=$3 WHERE order_uid=$1`, orderUID, doc, encodedTimes)
+	return true, err
+}
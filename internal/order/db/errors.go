@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"task1/internal/order"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// classify приводит ошибку драйвера к ошибкам order.Err*, оставляя исходную
+// в цепочке. Ошибки, которые уже классифицированы или не распознаны, не меняются.
+func classify(err error) error {
+	if err == nil || isClassified(err) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", order.ErrNotFound, err)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505", pgErr.Code == "23503":
+			// unique/foreign key violation
+			return fmt.Errorf("%w: %w", order.ErrConflict, err)
+		case strings.HasPrefix(pgErr.Code, "22"), pgErr.Code == "23502", pgErr.Code == "23514":
+			// data exception, not null и check violation
+			return &order.ValidationError{Fields: []order.FieldError{{Field: pgErr.ColumnName, Rule: pgErr.Code}}}
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "53"),
+			strings.HasPrefix(pgErr.Code, "57P"), pgErr.Code == "40001", pgErr.Code == "40P01":
+			// connection exception, insufficient resources, admin/crash shutdown;
+			// serialization failure и deadlock проходят при повторе транзакции
+			return fmt.Errorf("%w: %w", order.ErrUnavailable, err)
+		}
+		return err
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.Timeout(err) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", order.ErrUnavailable, err)
+	}
+	return err
+}
+
+func isClassified(err error) bool {
+	return errors.Is(err, order.ErrNotFound) || errors.Is(err, order.ErrValidation) ||
+		errors.Is(err, order.ErrConflict) || errors.Is(err, order.ErrUnavailable)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"task1/internal/order"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"нет строк", pgx.ErrNoRows, order.ErrNotFound},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, order.ErrConflict},
+		{"foreign key violation", &pgconn.PgError{Code: "23503"}, order.ErrConflict},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, order.ErrUnavailable},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, order.ErrUnavailable},
+		{"not null violation", &pgconn.PgError{Code: "23502"}, order.ErrValidation},
+		{"data exception", &pgconn.PgError{Code: "22003"}, order.ErrValidation},
+		{"connection exception", &pgconn.PgError{Code: "08006"}, order.ErrUnavailable},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, order.ErrUnavailable},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.err); !errors.Is(got, tt.want) {
+			t.Errorf("%s: classify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"task1/internal/order"
+	"task1/pkg/client"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ApplyEvent применяет событие под блокировкой строки заказа. У статуса
+// заказа, каждого товара и оплаты хранится время последнего применённого
+// события; более старые события их не меняют, поэтому порядок доставки
+// сообщений не важен. Не найденные товары считаются в Stale вместе с
+// устаревшими.
+func (r *Repository) ApplyEvent(ctx context.Context, ev order.StatusEvent) (order.EventResult, error) {
+	ctx, span := tracer.Start(ctx, "UPDATE order from status event", trace.WithAttributes(
+		attribute.String("event.id", ev.EventID), attribute.String("event.source", ev.Source)))
+	defer span.End()
+	var result order.EventResult
+	if err := ev.Validate(); err != nil {
+		return result, err
+	}
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return result, classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		current       order.Status
+		statusEventAt *time.Time
+	)
+	lookup := `SELECT order_uid, status, status_event_at FROM orders WHERE order_uid=$1 FOR UPDATE`
+	key := ev.OrderUID
+	if key == "" {
+		// track_number не уникален: берём последний заказ с ним
+		lookup = `SELECT order_uid, status, status_event_at FROM orders WHERE track_number=$1
+			ORDER BY date_created DESC LIMIT 1 FOR UPDATE`
+		key = ev.TrackNumber
+	}
+	if err := tx.QueryRow(ctx, lookup, key).Scan(&result.OrderUID, &current, &statusEventAt); err != nil {
+		return result, classify(fmt.Errorf("заказ %s: %w", key, err))
+	}
+	span.SetAttributes(attribute.String("order_uid", result.OrderUID))
+	before, err := r.loadTx(ctx, tx, result.OrderUID)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении заказа", "error", err)
+		return result, classify(err)
+	}
+	// точки маршрута не входят в Order, их добавляем в diff отдельно
+	checkpoints := make(map[string]order.FieldChange)
+	count := func(applied bool) {
+		if applied {
+			result.Applied++
+		} else {
+			result.Stale++
+		}
+	}
+
+	if ev.Status != "" {
+		applied, err := r.applyStatus(ctx, tx, ev, result.OrderUID, current, statusEventAt)
+		if err != nil {
+			return result, err
+		}
+		count(applied)
+	}
+	if cp := ev.Checkpoint; cp != nil {
+		eventTime, source := cp.EventTime, cp.Source
+		if eventTime.IsZero() {
+			eventTime = ev.EventTime
+		}
+		if source == "" {
+			source = ev.Source
+		}
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO delivery_checkpoints (order_uid, code, location, description, source, event_time)
+			VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+			ON CONFLICT (order_uid, code, event_time) DO NOTHING`,
+			result.OrderUID, cp.Code, cp.Location, cp.Description, source, eventTime)
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при записи точки маршрута", "error", err)
+			return result, classify(err)
+		}
+		if tag.RowsAffected() > 0 {
+			checkpoints["delivery.checkpoints["+cp.Code+"]"] = order.FieldChange{After: order.Checkpoint{
+				Code: cp.Code, Location: cp.Location, Description: cp.Description, EventTime: eventTime, Source: source}}
+		}
+		count(tag.RowsAffected() > 0)
+	}
+	handled := false
+	if r.mode == ModeDocument {
+		if handled, err = r.applyDocEvent(ctx, tx, ev, result.OrderUID, count); err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при обновлении orders.doc", "error", err)
+			return result, classify(err)
+		}
+	}
+	if !handled {
+		if err := r.applyRowsEvent(ctx, tx, ev, result.OrderUID, count); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Applied > 0 {
+		if err := r.syncDoc(ctx, tx, result.OrderUID); err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при обновлении orders.doc", "error", err)
+			return result, classify(err)
+		}
+		after, err := r.loadTx(ctx, tx, result.OrderUID)
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при чтении заказа", "error", err)
+			return result, classify(err)
+		}
+		diff := order.Diff(&before, &after)
+		for path, change := range checkpoints {
+			diff[path] = change
+		}
+		if err := insertAudit(ctx, tx, result.OrderUID, order.AuditEvent, diff); err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при записи журнала изменений", "error", err)
+			return result, classify(err)
+		}
+	}
+
+	client.MarkWritten(tx, result.OrderUID)
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при коммите транзакции", "error", err)
+		return result, classify(err)
+	}
+	return result, nil
+}
+
+// applyRowsEvent применяет товары и оплату события к строкам items и payment.
+func (r *Repository) applyRowsEvent(ctx context.Context, tx pgx.Tx, ev order.StatusEvent, orderUID string, count func(bool)) error {
+	for _, item := range ev.Items {
+		tag, err := tx.Exec(ctx,
+			`UPDATE items SET status=$4, status_event_at=$5
+			WHERE order_uid=$1 AND (($2 <> 0 AND chrt_id=$2) OR ($3 <> '' AND rid=$3))
+			AND (status_event_at IS NULL OR status_event_at < $5)`,
+			orderUID, item.ChrtID, item.Rid, item.Status, ev.EventTime)
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при обновлении статуса товара", "error", err)
+			return classify(err)
+		}
+		count(tag.RowsAffected() > 0)
+	}
+	if p := ev.Payment; p != nil {
+		tag, err := tx.Exec(ctx,
+			`UPDATE payment SET
+				transaction=COALESCE(NULLIF($2, ''), transaction),
+				provider=COALESCE(NULLIF($3, ''), provider),
+				bank=COALESCE(NULLIF($4, ''), bank),
+				payment_dt=COALESCE(NULLIF($5, 0), payment_dt),
+				event_at=$6
+			WHERE order_uid=$1 AND (event_at IS NULL OR event_at < $6)`,
+			orderUID, p.Transaction, p.Provider, p.Bank, p.PaymentDT, ev.EventTime)
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при обновлении оплаты", "error", err)
+			return classify(err)
+		}
+		count(tag.RowsAffected() > 0)
+	}
+	return nil
+}
+
+// applyStatus меняет статус заказа, если событие новее последнего
+// применённого. Промежуточные статусы могут прийти позже или не прийти
+// вовсе, поэтому достаточно, чтобы новый статус был достижим из текущего.
+// Повтор текущего статуса только сдвигает время.
+func (r *Repository) applyStatus(ctx context.Context, tx pgx.Tx, ev order.StatusEvent, orderUID string, current order.Status, eventAt *time.Time) (bool, error) {
+	if eventAt != nil && !ev.EventTime.After(*eventAt) {
+		return false, nil
+	}
+	if current != ev.Status {
+		if !current.Reachable(ev.Status) {
+			return false, fmt.Errorf("%w: %s → %s", order.ErrInvalidTransition, current, ev.Status)
+		}
+		change := order.StatusChange{OrderUID: orderUID, From: current, To: ev.Status, Actor: order.ActorFromContext(ctx), Reason: ev.Reason()}
+		if _, err := insertStatusChange(ctx, tx, change); err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при записи истории статусов", "error", err)
+			return false, classify(err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE orders SET status=$2, status_event_at=$3, doc=jsonb_set(doc, '{status}', to_jsonb($2::text))
+		WHERE order_uid=$1`, orderUID, ev.Status, ev.EventTime); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при обновлении статуса", "error", err)
+		return false, classify(err)
+	}
+	return true, nil
+}
+
+func (r *Repository) Checkpoints(ctx context.Context, id string) ([]order.Checkpoint, error) {
+	ctx, span := tracer.Start(ctx, "SELECT delivery checkpoints", trace.WithAttributes(attribute.String("order_uid", id)))
+	defer span.End()
+	rows, err := r.client.Query(ctx,
+		`SELECT code, COALESCE(location, ''), COALESCE(description, ''), event_time, COALESCE(source, '')
+		FROM delivery_checkpoints WHERE order_uid=$1 ORDER BY event_time, id`, id)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении точек маршрута", "error", err)
+		return nil, classify(err)
+	}
+	checkpoints, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (order.Checkpoint, error) {
+		var c order.Checkpoint
+		err := row.Scan(&c.Code, &c.Location, &c.Description, &c.EventTime, &c.Source)
+		return c, err
+	})
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении точек маршрута", "error", err)
+		return nil, classify(err)
+	}
+	return checkpoints, nil
+}
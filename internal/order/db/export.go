@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"task1/internal/order"
+	"task1/internal/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// exportFetchSize — сколько строк JOIN'а забирать с курсора за один FETCH.
+const exportFetchSize = 500
+
+// Stream обходит заказы по фильтру через серверный курсор и отдаёт их в fn
+// по одному, не держа всю выборку в памяти. Порядок — по date_created возрастанию.
+// Limit > 0 ограничивает число заказов, Offset не поддерживается.
+func (r *Repository) Stream(ctx context.Context, filter order.SearchFilter, fn func(order.Order) error) (err error) {
+	ctx, span := tracer.Start(ctx, "SELECT orders stream")
+	var streamed int
+	defer func() {
+		span.SetAttributes(attribute.Int("orders.count", streamed))
+		tracing.End(span, err)
+	}()
+
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	where, args := filterConditions(filter)
+	count := func(ord order.Order) error {
+		streamed++
+		return fn(ord)
+	}
+	if r.mode != ModeRelational {
+		return streamDocs(ctx, tx, r.Logger, `SELECT o.order_uid, o.doc FROM orders o `+where+`
+		ORDER BY o.date_created, o.order_uid`, args, filter.Limit, count)
+	}
+	return streamRows(ctx, tx, r.Logger, `SELECT `+orderColumns+` `+orderJoins+` `+where+`
+		ORDER BY o.date_created, o.order_uid, i.item_id`, args, filter.Limit, count)
+}
+
+// streamRows читает курсором строки JOIN'а по query (столбцы orderColumns,
+// упорядочены по заказу) и собирает из них заказы. Курсор закрывается в
+// конце, так что в одной транзакции можно читать несколько выборок подряд.
+func streamRows(ctx context.Context, tx pgx.Tx, logger *slog.Logger, query string, args []any, limit int, fn func(order.Order) error) error {
+	_, err := tx.Exec(ctx, `DECLARE orders_export NO SCROLL CURSOR FOR `+query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Ошибка при объявлении курсора выгрузки", "error", err)
+		return classify(err)
+	}
+	defer tx.Exec(ctx, `CLOSE orders_export`)
+
+	streamed := 0
+	var current *order.Order
+	emit := func() error {
+		if current == nil {
+			return nil
+		}
+		streamed++
+		ord := *current
+		current = nil
+		return fn(ord)
+	}
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM orders_export", exportFetchSize))
+		if err != nil {
+			logger.ErrorContext(ctx, "Ошибка при чтении курсора выгрузки", "error", err)
+			return classify(err)
+		}
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			o, err := scanOrderRow(rows)
+			if err != nil {
+				rows.Close()
+				logger.ErrorContext(ctx, "Ошибка при чтении orders", "error", err)
+				return classify(err)
+			}
+			if current != nil && current.OrderUID == o.OrderUID {
+				current.Items = append(current.Items, o.Items...)
+				continue
+			}
+			if err := emit(); err != nil {
+				rows.Close()
+				return err
+			}
+			if limit > 0 && streamed >= limit {
+				rows.Close()
+				return nil
+			}
+			current = &o
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			logger.ErrorContext(ctx, "Ошибка при чтении курсора выгрузки", "error", err)
+			return classify(err)
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+	return emit()
+}
+
+// streamDocs — streamRows для режимов с doc: query выбирает order_uid и doc,
+// одна строка курсора на заказ. Заказы без doc дочитываются из таблиц в той
+// же транзакции.
+func streamDocs(ctx context.Context, tx pgx.Tx, logger *slog.Logger, query string, args []any, limit int, fn func(order.Order) error) error {
+	_, err := tx.Exec(ctx, `DECLARE orders_export NO SCROLL CURSOR FOR `+query, args...)
+	if err != nil {
+		logger.ErrorContext(ctx, "Ошибка при объявлении курсора выгрузки", "error", err)
+		return classify(err)
+	}
+	defer tx.Exec(ctx, `CLOSE orders_export`)
+
+	streamed := 0
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM orders_export", exportFetchSize))
+		if err != nil {
+			logger.ErrorContext(ctx, "Ошибка при чтении курсора выгрузки", "error", err)
+			return classify(err)
+		}
+		type docRow struct {
+			id  string
+			doc []byte
+		}
+		var batch []docRow
+		for rows.Next() {
+			var row docRow
+			if err := rows.Scan(&row.id, &row.doc); err != nil {
+				rows.Close()
+				logger.ErrorContext(ctx, "Ошибка при чтении orders", "error", err)
+				return classify(err)
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			logger.ErrorContext(ctx, "Ошибка при чтении курсора выгрузки", "error", err)
+			return classify(err)
+		}
+		// строки выборки прочитаны целиком: дочитывать из таблиц можно только
+		// после закрытия rows
+		for _, row := range batch {
+			var ord order.Order
+			if row.doc == nil {
+				ord, err = findByIdTx(ctx, tx, row.id)
+			} else {
+				ord, err = decodeDoc(row.doc)
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "Ошибка при чтении orders", "error", err)
+				return classify(err)
+			}
+			if err := fn(ord); err != nil {
+				return err
+			}
+			streamed++
+			if limit > 0 && streamed >= limit {
+				return nil
+			}
+		}
+		if len(batch) < exportFetchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"task1/internal/order"
+	"task1/internal/tracing"
+	"task1/pkg/client"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// partitionedTables секционированы по date_created помесячно. Дочерние
+// таблицы идут первыми: секцию orders нельзя отсоединить, пока на неё
+// ссылаются внешние ключи секций доставки, оплаты и товаров.
+var partitionedTables = []string{"items", "payment", "delivery", "orders"}
+
+var partitionName = regexp.MustCompile(`^orders_y(\d{4})m(\d{2})$`)
+
+// Partitions управляет месячными секциями заказов.
+type Partitions struct {
+	client client.CLient
+	Logger *slog.Logger
+}
+
+func NewPartitions(client client.CLient, logger *slog.Logger) *Partitions {
+	return &Partitions{
+		client: client,
+		Logger: logger,
+	}
+}
+
+// PartitionName возвращает суффикс секции месяца month: y2024m01.
+func PartitionName(month time.Time) string {
+	return fmt.Sprintf("y%04dm%02d", month.Year(), month.Month())
+}
+
+// Create создаёт секции всех таблиц за месяц month, если их ещё нет.
+func (p *Partitions) Create(ctx context.Context, month time.Time) error {
+	ctx, span := tracer.Start(ctx, "CREATE order partitions", trace.WithAttributes(attribute.String("partition", PartitionName(month))))
+	defer span.End()
+	if _, err := p.client.Exec(ctx, `SELECT create_order_partitions($1::date)`, month.Format(time.DateOnly)); err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при создании секций", "error", err, "partition", PartitionName(month))
+		return classify(err)
+	}
+	return nil
+}
+
+// Months возвращает месяцы существующих секций orders по возрастанию;
+// секция по умолчанию не входит.
+func (p *Partitions) Months(ctx context.Context) ([]time.Time, error) {
+	return p.months(ctx, `SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'orders'::regclass`)
+}
+
+// Detached возвращает месяцы отсоединённых, но ещё не удалённых секций:
+// они остаются, если процесс упал между Detach и Drop.
+func (p *Partitions) Detached(ctx context.Context) ([]time.Time, error) {
+	return p.months(ctx, `SELECT c.relname FROM pg_class c
+		WHERE c.relkind = 'r' AND NOT c.relispartition AND c.relname LIKE 'orders\_y%'`)
+}
+
+// DefaultMonths возвращает месяцы строк секции по умолчанию старше before:
+// их секции не было, когда заказы сохранялись.
+func (p *Partitions) DefaultMonths(ctx context.Context, before time.Time) ([]time.Time, error) {
+	rows, err := p.client.Query(ctx,
+		`SELECT DISTINCT to_char(date_trunc('month', date_created), 'YYYY-MM-DD') FROM orders_default
+		WHERE date_created < $1`, before.UTC())
+	if err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при чтении месяцев секции по умолчанию", "error", err)
+		return nil, classify(err)
+	}
+	defer rows.Close()
+	var months []time.Time
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, classify(err)
+		}
+		month, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, err
+		}
+		months = append(months, month)
+	}
+	if err := rows.Err(); err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при чтении месяцев секции по умолчанию", "error", err)
+		return nil, classify(err)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+	return months, nil
+}
+
+func (p *Partitions) months(ctx context.Context, query string) ([]time.Time, error) {
+	rows, err := p.client.Query(ctx, query)
+	if err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при чтении списка секций", "error", err)
+		return nil, classify(err)
+	}
+	defer rows.Close()
+	var months []time.Time
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, classify(err)
+		}
+		match := partitionName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		months = append(months, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC))
+	}
+	if err := rows.Err(); err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при чтении списка секций", "error", err)
+		return nil, classify(err)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+	return months, nil
+}
+
+// Detach отсоединяет секции месяца month одной транзакцией: новые заказы
+// этого месяца уходят в секцию по умолчанию, а отсоединённые таблицы больше
+// не меняются и их можно выгрузить в архив. Внешние ключи дочерних таблиц на
+// orders снимаются, иначе секцию orders не отсоединить.
+func (p *Partitions) Detach(ctx context.Context, month time.Time) error {
+	suffix := PartitionName(month)
+	ctx, span := tracer.Start(ctx, "DETACH order partitions", trace.WithAttributes(attribute.String("partition", suffix)))
+	defer span.End()
+	tx, err := p.client.Begin(ctx)
+	if err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, table := range partitionedTables {
+		partition := table + "_" + suffix
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`, table, partition)); err != nil {
+			p.Logger.ErrorContext(ctx, "Ошибка при отсоединении секции", "error", err, "partition", partition)
+			return classify(err)
+		}
+		rows, err := tx.Query(ctx, `SELECT conname FROM pg_constraint WHERE conrelid = $1::regclass AND contype = 'f'`, partition)
+		if err != nil {
+			p.Logger.ErrorContext(ctx, "Ошибка при чтении внешних ключей секции", "error", err, "partition", partition)
+			return classify(err)
+		}
+		var constraints []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return classify(err)
+			}
+			constraints = append(constraints, name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			p.Logger.ErrorContext(ctx, "Ошибка при чтении внешних ключей секции", "error", err, "partition", partition)
+			return classify(err)
+		}
+		for _, name := range constraints {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT %s`, partition, pgx.Identifier{name}.Sanitize())); err != nil {
+				p.Logger.ErrorContext(ctx, "Ошибка при снятии внешнего ключа секции", "error", err, "partition", partition)
+				return classify(err)
+			}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при коммите транзакции", "error", err)
+		return classify(err)
+	}
+	return nil
+}
+
+// Stream обходит заказы отсоединённых секций месяца month. Заказы с doc
+// читаются из него, остальные — из таблиц секции.
+func (p *Partitions) Stream(ctx context.Context, month time.Time, fn func(order.Order) error) (err error) {
+	suffix := PartitionName(month)
+	ctx, span := tracer.Start(ctx, "SELECT order partitions stream", trace.WithAttributes(attribute.String("partition", suffix)))
+	defer func() { tracing.End(span, err) }()
+	tx, err := p.client.Begin(ctx)
+	if err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	joins := fmt.Sprintf(`FROM orders_%[1]s o LEFT JOIN delivery_%[1]s d ON o.order_uid=d.order_uid
+		LEFT JOIN payment_%[1]s p ON o.order_uid=p.order_uid
+		LEFT JOIN items_%[1]s i ON o.order_uid=i.order_uid`, suffix)
+	err = streamRows(ctx, tx, p.Logger, `SELECT `+orderColumns+` `+joins+` WHERE o.doc IS NULL
+		ORDER BY o.date_created, o.order_uid, i.item_id`, nil, 0, fn)
+	if err != nil {
+		return err
+	}
+	return streamDocs(ctx, tx, p.Logger, `SELECT o.order_uid, o.doc FROM orders_`+suffix+` o WHERE o.doc IS NOT NULL
+		ORDER BY o.date_created, o.order_uid`, nil, 0, fn)
+}
+
+// Drop удаляет отсоединённые через Detach секции месяца month одной
+// транзакцией. История статусов и точки маршрута заказов секции удаляются
+// вместе с ними, как раньше их удалял ON DELETE CASCADE; журнал аудита остаётся.
+func (p *Partitions) Drop(ctx context.Context, month time.Time) error {
+	suffix := PartitionName(month)
+	ctx, span := tracer.Start(ctx, "DROP order partitions", trace.WithAttributes(attribute.String("partition", suffix)))
+	defer span.End()
+	tx, err := p.client.Begin(ctx)
+	if err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	orders := "orders_" + suffix
+	for _, table := range []string{"order_status_history", "delivery_checkpoints"} {
+		_, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE order_uid IN (SELECT order_uid FROM %s)`, table, orders))
+		if err != nil {
+			p.Logger.ErrorContext(ctx, "Ошибка при удалении строк секции", "error", err, "table", table)
+			return classify(err)
+		}
+	}
+	for _, table := range partitionedTables {
+		partition := table + "_" + suffix
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`DROP TABLE %s`, partition)); err != nil {
+			p.Logger.ErrorContext(ctx, "Ошибка при удалении секции", "error", err, "partition", partition)
+			return classify(err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		p.Logger.ErrorContext(ctx, "Ошибка при коммите транзакции", "error", err)
+		return classify(err)
+	}
+	return nil
+}
@@ -5,212 +5,265 @@ import (
 	"fmt"
 	"log/slog"
 	"task1/internal/order"
+	"task1/internal/tracing"
 	"task1/pkg/client"
+	"time"
 
-	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("task1/internal/order/db")
+
 type Repository struct {
 	client client.CLient
 	Logger *slog.Logger
+	mode   StorageMode
 }
 
-func NewRepository(client client.CLient, logger *slog.Logger) order.Repository {
+func NewRepository(client client.CLient, logger *slog.Logger, mode StorageMode) order.Repository {
 	return &Repository{
 		client: client,
 		Logger: logger,
+		mode:   mode,
 	}
 }
+
+// reader — куда отправлять чтения FindAll, FindById и поиска: реплика, если
+// client умеет выбирать её, иначе тот же client. keys — order_uid читаемых
+// заказов, чтобы только что записанные читались с основной бд.
+func (r *Repository) reader(ctx context.Context, keys ...string) client.CLient {
+	if router, ok := r.client.(client.ReadRouter); ok {
+		return router.Reader(ctx, keys...)
+	}
+	return r.client
+}
 func (r *Repository) Save(ctx context.Context, ord order.Order) (string, error) {
 	tx, err := r.client.Begin(ctx)
 	if err != nil {
-		r.Logger.Error("Ошибка при создании транзакции", "error", err)
-		return "", err
+		r.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return "", classify(err)
 	}
 	defer tx.Rollback(ctx)
 
-	if err := validateOrder(ord); err != nil {
+	if err := validateOrder(ctx, ord); err != nil {
 		return "", err
 	}
+	orderUID, err := r.insertOrder(ctx, tx, ord)
+	if err != nil {
+		return "", classify(err)
+	}
+	client.MarkWritten(tx, orderUID)
+
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при коммите транзакции", "error", err)
+		return "", classify(err)
+	}
+
+	return orderUID, nil
+}
+
+// SaveBatch сохраняет заказы одной транзакцией: либо все, либо ни одного.
+func (r *Repository) SaveBatch(ctx context.Context, orders []order.Order) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "INSERT orders batch", trace.WithAttributes(attribute.Int("orders.count", len(orders))))
+	defer span.End()
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return nil, classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]string, 0, len(orders))
+	for _, ord := range orders {
+		if err := validateOrder(ctx, ord); err != nil {
+			return nil, err
+		}
+		orderUID, err := r.insertOrder(ctx, tx, ord)
+		if err != nil {
+			return nil, classify(err)
+		}
+		ids = append(ids, orderUID)
+	}
+	client.MarkWritten(tx, ids...)
+
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при коммите транзакции", "error", err)
+		return nil, classify(err)
+	}
+	return ids, nil
+}
 
-	var orderUID string
-	err = tx.QueryRow(ctx,
+// insertOrder пишет заказ со всеми дочерними строками в открытую транзакцию.
+// Дочерние строки получают date_created заказа: по нему секционированы все
+// четыре таблицы.
+func (r *Repository) insertOrder(ctx context.Context, tx pgx.Tx, ord order.Order) (string, error) {
+	var (
+		orderUID    string
+		dateCreated time.Time
+	)
+	spanCtx, span := tracer.Start(ctx, "INSERT orders")
+	err := tx.QueryRow(spanCtx,
 		`INSERT INTO orders (
 			track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
-		RETURNING order_uid`,
+			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, tags
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,COALESCE($11::text[], '{}'))
+		RETURNING order_uid, date_created`,
 		ord.TrackNumber, ord.Entry, ord.Locale, ord.InternalSignature,
 		ord.CustomerID, ord.DeliveryService, ord.ShardKey, ord.SmID,
-		ord.DateCreated, ord.OofShard,
-	).Scan(&orderUID)
+		ord.DateCreated, ord.OofShard, ord.Tags,
+	).Scan(&orderUID, &dateCreated)
+	span.SetAttributes(attribute.String("order_uid", orderUID))
+	tracing.End(span, err)
 	if err != nil {
-		r.Logger.Error("Ошибка при вставке order", "error", err)
+		r.Logger.ErrorContext(ctx, "Ошибка при вставке order", "error", err)
+		return "", err
+	}
+	if _, err := insertStatusChange(ctx, tx, order.StatusChange{OrderUID: orderUID, To: order.StatusCreated, Actor: order.ActorFromContext(ctx)}); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при записи истории статусов", "error", err)
 		return "", err
 	}
 
-	_, err = tx.Exec(ctx,
+	created := ord
+	created.OrderUID, created.DateCreated, created.Status = orderUID, dateCreated, order.StatusCreated
+	if r.mode == ModeDocument {
+		if created, err = r.insertDoc(ctx, tx, created); err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при записи orders.doc", "error", err)
+			return "", err
+		}
+		return orderUID, r.auditCreate(ctx, tx, created)
+	}
+
+	spanCtx, span = tracer.Start(ctx, "INSERT delivery")
+	_, err = tx.Exec(spanCtx,
 		`INSERT INTO delivery (
-			 order_uid, name, phone, zip, city, address, region, email
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
-		orderUID, ord.Delivery.Name, ord.Delivery.Phone,
+			 order_uid, date_created, name, phone, zip, city, address, region, email
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+		orderUID, dateCreated, ord.Delivery.Name, ord.Delivery.Phone,
 		ord.Delivery.Zip, ord.Delivery.City, ord.Delivery.Address,
 		ord.Delivery.Region, ord.Delivery.Email,
 	)
+	tracing.End(span, err)
 	if err != nil {
-		r.Logger.Error("Ошибка при вставке delivery", "error", err)
+		r.Logger.ErrorContext(ctx, "Ошибка при вставке delivery", "error", err)
 		return "", err
 	}
 
-	_, err = tx.Exec(ctx,
+	spanCtx, span = tracer.Start(ctx, "INSERT payment")
+	_, err = tx.Exec(spanCtx,
 		`INSERT INTO payment (
-			 order_uid, transaction, request_id, currency, provider,
+			 order_uid, date_created, transaction, request_id, currency, provider,
 			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
-		orderUID, ord.Payment.Transaction, ord.Payment.RequestID,
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`,
+		orderUID, dateCreated, ord.Payment.Transaction, ord.Payment.RequestID,
 		ord.Payment.Currency, ord.Payment.Provider, ord.Payment.Amount,
 		ord.Payment.PaymentDT, ord.Payment.Bank, ord.Payment.DeliveryCost,
 		ord.Payment.GoodsTotal, ord.Payment.CustomFee,
 	)
+	tracing.End(span, err)
 	if err != nil {
-		r.Logger.Error("Ошибка при вставке payment", "error", err)
+		r.Logger.ErrorContext(ctx, "Ошибка при вставке payment", "error", err)
 		return "", err
 	}
 
 	for _, item := range ord.Items {
-		_, err = tx.Exec(ctx,
+		spanCtx, span = tracer.Start(ctx, "INSERT items")
+		_, err = tx.Exec(spanCtx,
 			`INSERT INTO items (
-				order_uid, chrt_id, track_number, price, rid,
+				order_uid, date_created, chrt_id, track_number, price, rid,
 				name, sale, size, total_price, nm_id, brand, status
-			) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`,
-			orderUID, item.ChrtID, item.TrackNumber, item.Price,
+			) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)`,
+			orderUID, dateCreated, item.ChrtID, item.TrackNumber, item.Price,
 			item.Rid, item.Name, item.Sale, item.Size, item.TotalPrice,
 			item.NmID, item.Brand, item.Status,
 		)
+		tracing.End(span, err)
 		if err != nil {
-			r.Logger.Error("Ошибка при вставке item", "error", err)
+			r.Logger.ErrorContext(ctx, "Ошибка при вставке item", "error", err)
 			return "", err
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		r.Logger.Error("Ошибка при коммите транзакции", "error", err)
-		return "", err
+	if r.mode == ModeDual {
+		if _, err := r.insertDoc(ctx, tx, created); err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при записи orders.doc", "error", err)
+			return "", err
+		}
 	}
+	return orderUID, r.auditCreate(ctx, tx, created)
+}
 
-	return orderUID, nil
+func (r *Repository) auditCreate(ctx context.Context, tx pgx.Tx, created order.Order) error {
+	if err := insertAudit(ctx, tx, created.OrderUID, order.AuditCreate, order.Diff(nil, &created)); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при записи журнала изменений", "error", err)
+		return err
+	}
+	return nil
 }
 
 func (r *Repository) FindAll(ctx context.Context) ([]order.Order, error) {
-	query := `SELECT o.order_uid,o.track_number,o.entry,o.locale,o.internal_signature,
-		o.customer_id,o.delivery_service,o.shardkey,o.sm_id,o.date_created,o.oof_shard,
-		d.delivery_id,d.name,d.phone,d.zip,d.city,d.address,d.region,d.email,
-		p.payment_id,p.transaction,p.request_id,p.currency,p.provider,p.amount,
-		p.payment_dt,p.bank,p.delivery_cost,p.goods_total,p.custom_fee,
-		i.item_id,i.chrt_id,i.track_number,i.price,i.rid,i.name,i.sale,i.size,i.total_price,
-		i.nm_id,i.brand,i.status
-		FROM orders o LEFT JOIN delivery d ON o.order_uid=d.order_uid
-		LEFT JOIN payment p ON o.order_uid=p.order_uid
-		LEFT JOIN items i ON o.order_uid=i.order_uid`
-	rows, err := r.client.Query(ctx, query)
-	if err != nil {
-		r.Logger.Error("Ошибка при чтении запросе FindAll", "error", err)
-		return nil, err
-	}
-	ordersMap := make(map[string]*order.Order)
-	defer rows.Close()
-	for rows.Next() {
-		var d order.Delivery
-		var p order.Payment
-		var i order.Item
-		var o order.Order
-		err = rows.Scan(
-			&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature,
-			&o.CustomerID, &o.DeliveryService, &o.ShardKey, &o.SmID, &o.DateCreated, &o.OofShard,
-			&d.DeliveryID, &d.Name, &d.Phone, &d.Zip, &d.City, &d.Address, &d.Region, &d.Email,
-			&p.PaymentID, &p.Transaction, &p.RequestID, &p.Currency, &p.Provider, &p.Amount,
-			&p.PaymentDT, &p.Bank, &p.DeliveryCost, &p.GoodsTotal, &p.CustomFee,
-			&i.ItemID, &i.ChrtID, &i.TrackNumber, &i.Price, &i.Rid, &i.Name, &i.Sale,
-			&i.Size, &i.TotalPrice, &i.NmID, &i.Brand, &i.Status,
-		)
+	if r.mode != ModeRelational {
+		orders, err := r.findDocs(ctx, nil, `SELECT order_uid, doc FROM orders`)
 		if err != nil {
-			r.Logger.Error("Ошибка при чтении orders", "error", err)
-			return nil, err
-		}
-		existingOrder, ok := ordersMap[o.OrderUID]
-		if !ok {
-			o.Delivery = &d
-			o.Payment = &p
-			o.Items = []*order.Item{}
-			ordersMap[o.OrderUID] = &o
-			existingOrder = &o
+			r.Logger.ErrorContext(ctx, "Ошибка при чтении запросе FindAll", "error", err)
+			return nil, classify(err)
 		}
-		existingOrder.Items = append(existingOrder.Items, &i)
+		return orders, nil
 	}
-	orders := make([]order.Order, 0, len(ordersMap))
-	for _, o := range ordersMap {
-		orders = append(orders, *o)
+	rows, err := r.reader(ctx).Query(ctx, `SELECT `+orderColumns+` `+orderJoins)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении запросе FindAll", "error", err)
+		return nil, classify(err)
+	}
+	defer rows.Close()
+	orders, err := collectOrders(rows)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении orders", "error", err)
+		return nil, classify(err)
 	}
 	return orders, nil
-
 }
+
 func (r *Repository) FindById(ctx context.Context, id string) (order.Order, error) {
+	ctx, span := tracer.Start(ctx, "SELECT order by id", trace.WithAttributes(attribute.String("order_uid", id)))
+	defer span.End()
 	var o order.Order
-	query := `SELECT o.order_uid,o.track_number,o.entry,o.locale,o.internal_signature,
-		o.customer_id,o.delivery_service,o.shardkey,o.sm_id,o.date_created,o.oof_shard,
-		d.delivery_id,d.name,d.phone,d.zip,d.city,d.address,d.region,d.email,
-		p.payment_id,p.transaction,p.request_id,p.currency,p.provider,p.amount,
-		p.payment_dt,p.bank,p.delivery_cost,p.goods_total,p.custom_fee,
-		i.item_id,i.chrt_id,i.track_number,i.price,i.rid,i.name,i.sale,i.size,i.total_price,
-		i.nm_id,i.brand,i.status
-		FROM orders o LEFT JOIN delivery d ON o.order_uid=d.order_uid
-		LEFT JOIN payment p ON o.order_uid=p.order_uid
-		LEFT JOIN items i ON o.order_uid=i.order_uid WHERE o.order_uid=$1`
-	rows, err := r.client.Query(ctx, query, id)
-	if err != nil {
-		r.Logger.Error("Ошибка при выполнении запроса FindByID", "error", err)
-		return o, err
+	if _, err := uuid.Parse(id); err != nil {
+		return o, fmt.Errorf("%w: некорректный order_uid %q", order.ErrNotFound, id)
 	}
-	defer rows.Close()
-	o.Items = []*order.Item{}
-	for rows.Next() {
-		var d order.Delivery
-		var p order.Payment
-		var i order.Item
-		err = rows.Scan(
-			&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature,
-			&o.CustomerID, &o.DeliveryService, &o.ShardKey, &o.SmID, &o.DateCreated, &o.OofShard,
-			&d.DeliveryID, &d.Name, &d.Phone, &d.Zip, &d.City, &d.Address, &d.Region, &d.Email,
-			&p.PaymentID, &p.Transaction, &p.RequestID, &p.Currency, &p.Provider, &p.Amount,
-			&p.PaymentDT, &p.Bank, &p.DeliveryCost, &p.GoodsTotal, &p.CustomFee,
-			&i.ItemID, &i.ChrtID, &i.TrackNumber, &i.Price, &i.Rid, &i.Name, &i.Sale,
-			&i.Size, &i.TotalPrice, &i.NmID, &i.Brand, &i.Status,
-		)
+	if r.mode != ModeRelational {
+		orders, err := r.findDocs(ctx, []string{id}, `SELECT order_uid, doc FROM orders WHERE order_uid=$1`, id)
 		if err != nil {
-			r.Logger.Error("Ошибка при сканировании строки FindById", "error", err)
-			return o, err
+			r.Logger.ErrorContext(ctx, "Ошибка при выполнении запроса FindByID", "error", err)
+			return o, classify(err)
 		}
-		if o.Delivery == nil {
-			o.Delivery = &d
+		if len(orders) == 0 {
+			return o, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
 		}
-		if o.Payment == nil {
-			o.Payment = &p
-		}
-		o.Items = append(o.Items, &i)
-		if len(o.Items) == 0 && o.Delivery == nil && o.Payment == nil {
-			return o, fmt.Errorf("заказ с order_uid=%s не найден", id)
-		}
-
+		return orders[0], nil
 	}
-	return o, nil
-}
-
-func validateOrder(ord order.Order) error {
-	validate := validator.New()
-	err := validate.Struct(ord)
+	rows, err := r.reader(ctx, id).Query(ctx, `SELECT `+orderColumns+` `+orderJoins+` WHERE o.order_uid=$1`, id)
 	if err != nil {
-		return err
+		r.Logger.ErrorContext(ctx, "Ошибка при выполнении запроса FindByID", "error", err)
+		return o, classify(err)
+	}
+	defer rows.Close()
+	orders, err := collectOrders(rows)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении строк FindById", "error", err)
+		return o, classify(err)
+	}
+	if len(orders) == 0 {
+		return o, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
 	}
+	return orders[0], nil
+}
 
-	return nil
+func validateOrder(ctx context.Context, ord order.Order) (err error) {
+	_, span := tracer.Start(ctx, "order.validate")
+	defer func() { tracing.End(span, err) }()
+	return ord.Validate()
 }
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"task1/internal/money"
+	"task1/internal/order"
+	"task1/pkg/migr"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Бенчмарки ходят в настоящую бд: TEST_DB_DSN указывает на базу, к которой
+// применяются миграции. Без него бенчмарк пропускается.
+func benchmarkPool(b *testing.B) *pgxpool.Pool {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		b.Skip("TEST_DB_DSN не задан")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		b.Fatalf("pgxpool.New: %v", err)
+	}
+	b.Cleanup(pool.Close)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := migr.NewMigrator(pool, logger).Migrate("../../../migrations"); err != nil {
+		b.Fatalf("Migrate: %v", err)
+	}
+	return pool
+}
+
+func benchmarkOrder() order.Order {
+	ord := order.Order{
+		TrackNumber: "BENCHTRACK", Entry: "WBIL", Locale: "en", CustomerID: "bench", DeliveryService: "meest",
+		ShardKey: "9", SmID: 99, DateCreated: time.Now().UTC().Truncate(time.Second), OofShard: "1",
+		Delivery: &order.Delivery{Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com"},
+		Payment: &order.Payment{Transaction: "bench", Currency: "USD", Provider: "wbpay",
+			PaymentDT: 1637907727, Bank: "alpha", DeliveryCost: money.New(150000, "USD"), CustomFee: money.New(0, "USD")},
+	}
+	var goods int64
+	for i := range 5 {
+		price := int64(10000 * (i + 1))
+		goods += price
+		ord.Items = append(ord.Items, &order.Item{ChrtID: 9934930 + i, TrackNumber: "BENCHTRACK", Price: money.New(price, "USD"),
+			Rid: "bench", Name: "Mascaras", Size: "0", TotalPrice: money.New(price, "USD"), NmID: 2389212, Brand: "Vivienne Sabo", Status: 202})
+	}
+	ord.Payment.GoodsTotal = money.New(goods, "USD")
+	ord.Payment.Amount = money.New(goods+150000, "USD")
+	return ord
+}
+
+func BenchmarkFindById(b *testing.B) {
+	pool := benchmarkPool(b)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, mode := range []StorageMode{ModeRelational, ModeDocument} {
+		b.Run(string(mode), func(b *testing.B) {
+			ctx := context.Background()
+			repo := NewRepository(pool, logger, mode)
+			id, err := repo.Save(ctx, benchmarkOrder())
+			if err != nil {
+				b.Fatalf("Save: %v", err)
+			}
+			b.ResetTimer()
+			for range b.N {
+				if _, err := repo.FindById(ctx, id); err != nil {
+					b.Fatalf("FindById: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task1/internal/order"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const orderColumns = `o.order_uid,o.track_number,o.entry,o.locale,o.internal_signature,
+		o.customer_id,o.delivery_service,o.shardkey,o.sm_id,o.date_created,o.oof_shard,o.status,o.tags,
+		d.delivery_id,d.name,d.phone,d.zip,d.city,d.address,d.region,d.email,
+		p.payment_id,p.transaction,p.request_id,p.currency,p.provider,p.amount,
+		p.payment_dt,p.bank,p.delivery_cost,p.goods_total,p.custom_fee,
+		i.item_id,i.chrt_id,i.track_number,i.price,i.rid,i.name,i.sale,i.size,i.total_price,
+		i.nm_id,i.brand,i.status`
+
+const orderJoins = `FROM orders o LEFT JOIN delivery d ON o.order_uid=d.order_uid
+		LEFT JOIN payment p ON o.order_uid=p.order_uid
+		LEFT JOIN items i ON o.order_uid=i.order_uid`
+
+func (r *Repository) Search(ctx context.Context, filter order.SearchFilter) ([]order.Order, error) {
+	ctx, span := tracer.Start(ctx, "SELECT orders search")
+	defer span.End()
+	where, args := filterConditions(filter)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = order.DefaultSearchLimit
+	}
+	limit = min(limit, order.MaxSearchLimit)
+	args = append(args, limit, max(filter.Offset, 0))
+	query := fmt.Sprintf(`SELECT o.order_uid FROM orders o %s
+		ORDER BY o.date_created DESC, o.order_uid LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+
+	rows, err := r.reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при выполнении запроса Search", "error", err)
+		return nil, classify(err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении order_uid в Search", "error", err)
+		return nil, classify(err)
+	}
+	span.SetAttributes(attribute.Int("orders.count", len(ids)))
+	if len(ids) == 0 {
+		return []order.Order{}, nil
+	}
+	return r.FindByIDs(ctx, ids)
+}
+
+// filterConditions строит WHERE по фильтру для запросов с алиасом orders o.
+func filterConditions(filter order.SearchFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.CustomerID != "" {
+		add("o.customer_id = $%d", filter.CustomerID)
+	}
+	if filter.DeliveryService != "" {
+		add("o.delivery_service = $%d", filter.DeliveryService)
+	}
+	if filter.TrackNumber != "" {
+		add("o.track_number = $%d", filter.TrackNumber)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		add("o.date_created >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		add("o.date_created < $%d", filter.CreatedTo)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// FindByIDs загружает заказы одним запросом; порядок — по date_created убыванию.
+// Ненайденные id просто отсутствуют в результате.
+func (r *Repository) FindByIDs(ctx context.Context, ids []string) ([]order.Order, error) {
+	ctx, span := tracer.Start(ctx, "SELECT orders by ids", trace.WithAttributes(attribute.Int("orders.requested", len(ids))))
+	defer span.End()
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err == nil {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return []order.Order{}, nil
+	}
+	if r.mode != ModeRelational {
+		orders, err := r.findDocs(ctx, valid, `SELECT order_uid, doc FROM orders WHERE order_uid = ANY($1::uuid[])
+			ORDER BY date_created DESC, order_uid`, valid)
+		if err != nil {
+			r.Logger.ErrorContext(ctx, "Ошибка при выполнении запроса по списку order_uid", "error", err)
+			return nil, classify(err)
+		}
+		return orders, nil
+	}
+	query := `SELECT ` + orderColumns + ` ` + orderJoins + `
+		WHERE o.order_uid = ANY($1::uuid[])
+		ORDER BY o.date_created DESC, o.order_uid`
+	rows, err := r.reader(ctx, valid...).Query(ctx, query, valid)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при выполнении запроса по списку order_uid", "error", err)
+		return nil, classify(err)
+	}
+	defer rows.Close()
+	orders, err := collectOrders(rows)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении orders", "error", err)
+		return nil, classify(err)
+	}
+	return orders, nil
+}
+
+// collectOrders собирает заказы из строк JOIN'а, сохраняя порядок первого появления.
+func collectOrders(rows pgx.Rows) ([]order.Order, error) {
+	var orders []*order.Order
+	byID := make(map[string]*order.Order)
+	for rows.Next() {
+		o, err := scanOrderRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		existing, ok := byID[o.OrderUID]
+		if !ok {
+			byID[o.OrderUID] = &o
+			orders = append(orders, &o)
+			continue
+		}
+		existing.Items = append(existing.Items, o.Items...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result := make([]order.Order, 0, len(orders))
+	for _, o := range orders {
+		result = append(result, *o)
+	}
+	return result, nil
+}
+
+// scanOrderRow читает одну строку JOIN'а: заказ с доставкой, оплатой и одним товаром.
+func scanOrderRow(row pgx.Row) (order.Order, error) {
+	var o order.Order
+	var d order.Delivery
+	var p order.Payment
+	var i order.Item
+	err := row.Scan(
+		&o.OrderUID, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature,
+		&o.CustomerID, &o.DeliveryService, &o.ShardKey, &o.SmID, &o.DateCreated, &o.OofShard, &o.Status, &o.Tags,
+		&d.DeliveryID, &d.Name, &d.Phone, &d.Zip, &d.City, &d.Address, &d.Region, &d.Email,
+		&p.PaymentID, &p.Transaction, &p.RequestID, &p.Currency, &p.Provider, &p.Amount,
+		&p.PaymentDT, &p.Bank, &p.DeliveryCost, &p.GoodsTotal, &p.CustomFee,
+		&i.ItemID, &i.ChrtID, &i.TrackNumber, &i.Price, &i.Rid, &i.Name, &i.Sale,
+		&i.Size, &i.TotalPrice, &i.NmID, &i.Brand, &i.Status,
+	)
+	if err != nil {
+		return o, err
+	}
+	o.Delivery = &d
+	o.Payment = &p
+	o.Items = []*order.Item{&i}
+	// лишние знаки после запятой не мешают чтению: сумма остаётся точной без валюты
+	_ = o.BindCurrency()
+	return o, nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"task1/internal/order"
+	"task1/internal/tracing"
+	"task1/pkg/client"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transition блокирует строку заказа на время проверки перехода, поэтому
+// конкурентные переходы одного заказа выполняются по очереди.
+func (r *Repository) Transition(ctx context.Context, id string, to order.Status, reason string) (order.StatusChange, error) {
+	ctx, span := tracer.Start(ctx, "UPDATE order status", trace.WithAttributes(
+		attribute.String("order_uid", id), attribute.String("order.status", string(to))))
+	defer span.End()
+	change := order.StatusChange{OrderUID: id, To: to, Actor: order.ActorFromContext(ctx), Reason: reason}
+	if _, err := uuid.Parse(id); err != nil {
+		return change, fmt.Errorf("%w: некорректный order_uid %q", order.ErrNotFound, id)
+	}
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при создании транзакции", "error", err)
+		return change, classify(err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `SELECT status FROM orders WHERE order_uid=$1 FOR UPDATE`, id).Scan(&change.From)
+	if err != nil {
+		return change, classify(err)
+	}
+	if err := order.CheckTransition(change.From, to); err != nil {
+		return change, err
+	}
+	// ручной переход новее любого уже отправленного события
+	_, err = tx.Exec(ctx, `UPDATE orders SET status=$2, status_event_at=now(), doc=jsonb_set(doc, '{status}', to_jsonb($2::text))
+		WHERE order_uid=$1`, id, to)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при обновлении статуса", "error", err)
+		return change, classify(err)
+	}
+	if err := r.syncDoc(ctx, tx, id); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при обновлении orders.doc", "error", err)
+		return change, classify(err)
+	}
+	if change, err = insertStatusChange(ctx, tx, change); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при записи истории статусов", "error", err)
+		return change, classify(err)
+	}
+	diff := map[string]order.FieldChange{"status": {Before: change.From, After: change.To}}
+	if err := insertAudit(ctx, tx, id, order.AuditStatus, diff); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при записи журнала изменений", "error", err)
+		return change, classify(err)
+	}
+	client.MarkWritten(tx, id)
+	if err := tx.Commit(ctx); err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при коммите транзакции", "error", err)
+		return change, classify(err)
+	}
+	return change, nil
+}
+
+func (r *Repository) StatusHistory(ctx context.Context, id string) ([]order.StatusChange, error) {
+	ctx, span := tracer.Start(ctx, "SELECT order status history", trace.WithAttributes(attribute.String("order_uid", id)))
+	defer span.End()
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: некорректный order_uid %q", order.ErrNotFound, id)
+	}
+	rows, err := r.client.Query(ctx,
+		`SELECT order_uid, COALESCE(from_status, ''), to_status, actor, COALESCE(reason, ''), changed_at
+		FROM order_status_history WHERE order_uid=$1 ORDER BY changed_at, id`, id)
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении истории статусов", "error", err)
+		return nil, classify(err)
+	}
+	history, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (order.StatusChange, error) {
+		var c order.StatusChange
+		err := row.Scan(&c.OrderUID, &c.From, &c.To, &c.Actor, &c.Reason, &c.ChangedAt)
+		return c, err
+	})
+	if err != nil {
+		r.Logger.ErrorContext(ctx, "Ошибка при чтении истории статусов", "error", err)
+		return nil, classify(err)
+	}
+	// у каждого заказа есть запись о создании, пустая история — нет заказа
+	if len(history) == 0 {
+		return nil, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
+	}
+	return history, nil
+}
+
+func insertStatusChange(ctx context.Context, tx pgx.Tx, change order.StatusChange) (_ order.StatusChange, err error) {
+	spanCtx, span := tracer.Start(ctx, "INSERT order_status_history")
+	defer func() { tracing.End(span, err) }()
+	err = tx.QueryRow(spanCtx,
+		`INSERT INTO order_status_history (order_uid, from_status, to_status, actor, reason)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''))
+		RETURNING changed_at`,
+		change.OrderUID, change.From, change.To, change.Actor, change.Reason,
+	).Scan(&change.ChangedAt)
+	return change, err
+}
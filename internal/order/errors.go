@@ -0,0 +1,61 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Ошибки репозитория; проверять через errors.Is. Исходная ошибка драйвера
+// сохраняется в цепочке для логов.
+var (
+	ErrNotFound    = errors.New("order not found")
+	ErrValidation  = errors.New("order validation failed")
+	ErrConflict    = errors.New("order conflicts with existing data")
+	ErrUnavailable = errors.New("order storage is unavailable")
+)
+
+// FieldError описывает одно нарушенное правило валидации.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+// ValidationError — ErrValidation с перечнем полей, не прошедших проверку.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Rule)
+	}
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(parts, ", "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// newValidationError переводит ошибки validator в FieldError с путями
+// в JSON-нотации, например delivery.phone или items[0].price.
+func newValidationError(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	fields := make([]FieldError, 0, len(verrs))
+	for _, v := range verrs {
+		_, path, _ := strings.Cut(v.Namespace(), ".")
+		fields = append(fields, FieldError{Field: path, Rule: v.Tag()})
+	}
+	return &ValidationError{Fields: fields}
+}
+
+func itemPath(i int, field string) string {
+	return "items[" + strconv.Itoa(i) + "]." + field
+}
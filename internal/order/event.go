@@ -0,0 +1,103 @@
+package order
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusEvent — изменение статусов от службы доставки, склада или платёжной
+// системы. Заказ ищется по OrderUID, если он пуст — по TrackNumber.
+// Заполняются только изменившиеся части.
+type StatusEvent struct {
+	EventID     string    `json:"event_id"`
+	EventTime   time.Time `json:"event_time"`
+	Source      string    `json:"source"`
+	OrderUID    string    `json:"order_uid"`
+	TrackNumber string    `json:"track_number"`
+	// Status — новый этап жизненного цикла заказа
+	Status     Status        `json:"status,omitempty"`
+	Items      []ItemStatus  `json:"items,omitempty"`
+	Checkpoint *Checkpoint   `json:"checkpoint,omitempty"`
+	Payment    *PaymentEvent `json:"payment,omitempty"`
+}
+
+// ItemStatus — код статуса товара; товар определяется по chrt_id или rid.
+type ItemStatus struct {
+	ChrtID int    `json:"chrt_id"`
+	Rid    string `json:"rid"`
+	Status int    `json:"status"`
+}
+
+// Checkpoint — точка маршрута доставки.
+type Checkpoint struct {
+	Code        string    `json:"code"`
+	Location    string    `json:"location,omitempty"`
+	Description string    `json:"description,omitempty"`
+	EventTime   time.Time `json:"event_time"`
+	Source      string    `json:"source,omitempty"`
+}
+
+// PaymentEvent обновляет непустые поля оплаты.
+type PaymentEvent struct {
+	Transaction string `json:"transaction,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	Bank        string `json:"bank,omitempty"`
+	PaymentDT   int    `json:"payment_dt,omitempty"`
+}
+
+// EventResult — что из события применено. Stale — части, для которых в бд
+// уже есть более позднее событие.
+type EventResult struct {
+	OrderUID string `json:"order_uid"`
+	Applied  int    `json:"applied"`
+	Stale    int    `json:"stale"`
+}
+
+// Reason — причина смены статуса в истории: источник и id события.
+func (e StatusEvent) Reason() string {
+	reason := "event"
+	if e.Source != "" {
+		reason += " from " + e.Source
+	}
+	if e.EventID != "" {
+		reason += " " + e.EventID
+	}
+	return reason
+}
+
+// Validate проверяет, что событие можно применить; ошибка — *ValidationError.
+func (e StatusEvent) Validate() error {
+	var fields []FieldError
+	if e.OrderUID == "" && e.TrackNumber == "" {
+		fields = append(fields, FieldError{Field: "order_uid", Rule: "required_without=track_number"})
+	}
+	if e.OrderUID != "" {
+		if _, err := uuid.Parse(e.OrderUID); err != nil {
+			fields = append(fields, FieldError{Field: "order_uid", Rule: "uuid"})
+		}
+	}
+	if e.EventTime.IsZero() {
+		fields = append(fields, FieldError{Field: "event_time", Rule: "required"})
+	}
+	if e.Status != "" {
+		if _, err := ParseStatus(string(e.Status)); err != nil {
+			fields = append(fields, FieldError{Field: "status", Rule: "oneof"})
+		}
+	}
+	for i, item := range e.Items {
+		if item.ChrtID == 0 && item.Rid == "" {
+			fields = append(fields, FieldError{Field: itemPath(i, "chrt_id"), Rule: "required_without=rid"})
+		}
+	}
+	if e.Checkpoint != nil && e.Checkpoint.Code == "" {
+		fields = append(fields, FieldError{Field: "checkpoint.code", Rule: "required"})
+	}
+	if e.Status == "" && len(e.Items) == 0 && e.Checkpoint == nil && e.Payment == nil {
+		fields = append(fields, FieldError{Field: "status", Rule: "required_without_all=items checkpoint payment"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
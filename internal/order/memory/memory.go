@@ -0,0 +1,456 @@
+// Package memory — order.Repository в памяти процесса для тестов и демо без
+// Postgres. Семантика та же, что у internal/order/db: UUID выдаёт хранилище,
+// заказы проверяются перед записью, отсутствующие — order.ErrNotFound.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"task1/internal/logging"
+	"task1/internal/order"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Repository struct {
+	mu     *sync.RWMutex
+	orders map[string]*entry
+	// журнал аудита переживает заказ, как таблица order_audit без внешнего ключа
+	audit       map[string][]order.AuditEntry
+	nextAuditID int64
+}
+
+// entry — заказ и то, что в бд хранится рядом с ним.
+type entry struct {
+	order          order.Order
+	history        []order.StatusChange
+	checkpoints    []order.Checkpoint
+	statusEventAt  *time.Time
+	itemEventAt    map[string]time.Time
+	paymentEventAt *time.Time
+}
+
+func NewRepository() order.Repository {
+	return &Repository{
+		mu:     &sync.RWMutex{},
+		orders: make(map[string]*entry),
+		audit:  make(map[string][]order.AuditEntry),
+	}
+}
+
+func (r *Repository) Save(ctx context.Context, ord order.Order) (string, error) {
+	if err := ord.Validate(); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(ctx, ord), nil
+}
+
+// SaveBatch сохраняет либо все заказы, либо ни одного.
+func (r *Repository) SaveBatch(ctx context.Context, orders []order.Order) ([]string, error) {
+	for _, ord := range orders {
+		if err := ord.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(orders))
+	for _, ord := range orders {
+		ids = append(ids, r.insert(ctx, ord))
+	}
+	return ids, nil
+}
+
+func (r *Repository) insert(ctx context.Context, ord order.Order) string {
+	ord = cloneOrder(ord)
+	ord.OrderUID = uuid.NewString()
+	ord.Status = order.StatusCreated
+	if ord.Tags == nil {
+		ord.Tags = []string{}
+	}
+	if ord.Delivery != nil {
+		ord.Delivery.DeliveryID = uuid.NewString()
+	}
+	if ord.Payment != nil {
+		ord.Payment.PaymentID = uuid.NewString()
+	}
+	for _, item := range ord.Items {
+		item.ItemID = uuid.NewString()
+	}
+	r.orders[ord.OrderUID] = &entry{
+		order: ord,
+		history: []order.StatusChange{{
+			OrderUID: ord.OrderUID, To: order.StatusCreated, Actor: order.ActorFromContext(ctx), ChangedAt: time.Now(),
+		}},
+		itemEventAt: make(map[string]time.Time),
+	}
+	r.appendAudit(ctx, ord.OrderUID, order.AuditCreate, order.Diff(nil, &ord))
+	return ord.OrderUID
+}
+
+func (r *Repository) FindAll(ctx context.Context) ([]order.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	orders := make([]order.Order, 0, len(r.orders))
+	for _, e := range r.orders {
+		orders = append(orders, cloneOrder(e.order))
+	}
+	sortNewestFirst(orders)
+	return orders, nil
+}
+
+func (r *Repository) FindById(ctx context.Context, id string) (order.Order, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return order.Order{}, fmt.Errorf("%w: некорректный order_uid %q", order.ErrNotFound, id)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.orders[id]
+	if !ok {
+		return order.Order{}, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
+	}
+	return cloneOrder(e.order), nil
+}
+
+// FindByIDs возвращает найденные заказы по date_created убыванию.
+func (r *Repository) FindByIDs(ctx context.Context, ids []string) ([]order.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[string]bool, len(ids))
+	orders := make([]order.Order, 0, len(ids))
+	for _, id := range ids {
+		if e, ok := r.orders[id]; ok && !seen[id] {
+			seen[id] = true
+			orders = append(orders, cloneOrder(e.order))
+		}
+	}
+	sortNewestFirst(orders)
+	return orders, nil
+}
+
+func (r *Repository) Search(ctx context.Context, filter order.SearchFilter) ([]order.Order, error) {
+	orders := r.filter(filter)
+	sortNewestFirst(orders)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = order.DefaultSearchLimit
+	}
+	limit = min(limit, order.MaxSearchLimit)
+	offset := min(max(filter.Offset, 0), len(orders))
+	return orders[offset:min(offset+limit, len(orders))], nil
+}
+
+// Stream отдаёт снимок выборки по date_created возрастанию; fn вызывается
+// без блокировки, поэтому может обращаться к репозиторию.
+func (r *Repository) Stream(ctx context.Context, filter order.SearchFilter, fn func(order.Order) error) error {
+	orders := r.filter(filter)
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].DateCreated.Equal(orders[j].DateCreated) {
+			return orders[i].DateCreated.Before(orders[j].DateCreated)
+		}
+		return orders[i].OrderUID < orders[j].OrderUID
+	})
+	for i, ord := range orders {
+		if filter.Limit > 0 && i >= filter.Limit {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(ord); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) filter(filter order.SearchFilter) []order.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var orders []order.Order
+	for _, e := range r.orders {
+		o := e.order
+		switch {
+		case filter.CustomerID != "" && o.CustomerID != filter.CustomerID,
+			filter.DeliveryService != "" && o.DeliveryService != filter.DeliveryService,
+			filter.TrackNumber != "" && o.TrackNumber != filter.TrackNumber,
+			!filter.CreatedFrom.IsZero() && o.DateCreated.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !o.DateCreated.Before(filter.CreatedTo):
+			continue
+		}
+		orders = append(orders, cloneOrder(o))
+	}
+	return orders
+}
+
+func (r *Repository) Transition(ctx context.Context, id string, to order.Status, reason string) (order.StatusChange, error) {
+	change := order.StatusChange{OrderUID: id, To: to, Actor: order.ActorFromContext(ctx), Reason: reason}
+	if _, err := uuid.Parse(id); err != nil {
+		return change, fmt.Errorf("%w: некорректный order_uid %q", order.ErrNotFound, id)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.orders[id]
+	if !ok {
+		return change, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
+	}
+	change.From = e.order.Status
+	if err := order.CheckTransition(change.From, to); err != nil {
+		return change, err
+	}
+	now := time.Now()
+	e.order.Status = to
+	e.statusEventAt = &now
+	change.ChangedAt = now
+	e.history = append(e.history, change)
+	r.appendAudit(ctx, id, order.AuditStatus, map[string]order.FieldChange{"status": {Before: change.From, After: change.To}})
+	return change, nil
+}
+
+func (r *Repository) StatusHistory(ctx context.Context, id string) ([]order.StatusChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
+	}
+	return append([]order.StatusChange(nil), e.history...), nil
+}
+
+// ApplyEvent повторяет internal/order/db: части события старше уже
+// применённых пропускаются, статус должен быть достижим из текущего.
+func (r *Repository) ApplyEvent(ctx context.Context, ev order.StatusEvent) (order.EventResult, error) {
+	var result order.EventResult
+	if err := ev.Validate(); err != nil {
+		return result, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.lookup(ev)
+	if e == nil {
+		key := ev.OrderUID
+		if key == "" {
+			key = ev.TrackNumber
+		}
+		return result, fmt.Errorf("%w: заказ %s", order.ErrNotFound, key)
+	}
+	result.OrderUID = e.order.OrderUID
+	statusApplies := ev.Status != "" && (e.statusEventAt == nil || ev.EventTime.After(*e.statusEventAt))
+	if statusApplies && e.order.Status != ev.Status && !e.order.Status.Reachable(ev.Status) {
+		return result, fmt.Errorf("%w: %s → %s", order.ErrInvalidTransition, e.order.Status, ev.Status)
+	}
+
+	// изменения готовятся на копии: при ошибке заказ не меняется
+	before := cloneOrder(e.order)
+	after := cloneOrder(e.order)
+	count := func(applied bool) {
+		if applied {
+			result.Applied++
+		} else {
+			result.Stale++
+		}
+	}
+	var (
+		change      *order.StatusChange
+		checkpoint  *order.Checkpoint
+		itemTimes   = make(map[string]time.Time)
+		paymentTime *time.Time
+	)
+	if ev.Status != "" {
+		if statusApplies && after.Status != ev.Status {
+			change = &order.StatusChange{OrderUID: after.OrderUID, From: after.Status, To: ev.Status,
+				Actor: order.ActorFromContext(ctx), Reason: ev.Reason(), ChangedAt: time.Now()}
+			after.Status = ev.Status
+		}
+		count(statusApplies)
+	}
+	for _, event := range ev.Items {
+		applied := false
+		for _, item := range after.Items {
+			if (event.ChrtID == 0 || item.ChrtID != event.ChrtID) && (event.Rid == "" || item.Rid != event.Rid) {
+				continue
+			}
+			if last, ok := e.itemEventAt[item.ItemID]; ok && !last.Before(ev.EventTime) {
+				continue
+			}
+			item.Status = event.Status
+			itemTimes[item.ItemID] = ev.EventTime
+			applied = true
+		}
+		count(applied)
+	}
+	if cp := ev.Checkpoint; cp != nil {
+		c := *cp
+		if c.EventTime.IsZero() {
+			c.EventTime = ev.EventTime
+		}
+		if c.Source == "" {
+			c.Source = ev.Source
+		}
+		applied := true
+		for _, existing := range e.checkpoints {
+			if existing.Code == c.Code && existing.EventTime.Equal(c.EventTime) {
+				applied = false
+			}
+		}
+		if applied {
+			checkpoint = &c
+		}
+		count(applied)
+	}
+	if p := ev.Payment; p != nil {
+		applied := after.Payment != nil && (e.paymentEventAt == nil || e.paymentEventAt.Before(ev.EventTime))
+		if applied {
+			if p.Transaction != "" {
+				after.Payment.Transaction = p.Transaction
+			}
+			if p.Provider != "" {
+				after.Payment.Provider = p.Provider
+			}
+			if p.Bank != "" {
+				after.Payment.Bank = p.Bank
+			}
+			if p.PaymentDT != 0 {
+				after.Payment.PaymentDT = p.PaymentDT
+			}
+			eventTime := ev.EventTime
+			paymentTime = &eventTime
+		}
+		count(applied)
+	}
+	if result.Applied == 0 {
+		return result, nil
+	}
+
+	e.order = after
+	if statusApplies {
+		eventTime := ev.EventTime
+		e.statusEventAt = &eventTime
+	}
+	if change != nil {
+		e.history = append(e.history, *change)
+	}
+	for id, t := range itemTimes {
+		e.itemEventAt[id] = t
+	}
+	if paymentTime != nil {
+		e.paymentEventAt = paymentTime
+	}
+	diff := order.Diff(&before, &after)
+	if checkpoint != nil {
+		e.checkpoints = append(e.checkpoints, *checkpoint)
+		sort.SliceStable(e.checkpoints, func(i, j int) bool {
+			return e.checkpoints[i].EventTime.Before(e.checkpoints[j].EventTime)
+		})
+		diff["delivery.checkpoints["+checkpoint.Code+"]"] = order.FieldChange{After: *checkpoint}
+	}
+	r.appendAudit(ctx, after.OrderUID, order.AuditEvent, diff)
+	return result, nil
+}
+
+// lookup ищет заказ по order_uid, а без него — последний с track_number.
+func (r *Repository) lookup(ev order.StatusEvent) *entry {
+	if ev.OrderUID != "" {
+		return r.orders[ev.OrderUID]
+	}
+	var found *entry
+	for _, e := range r.orders {
+		if e.order.TrackNumber == ev.TrackNumber && (found == nil || e.order.DateCreated.After(found.order.DateCreated)) {
+			found = e
+		}
+	}
+	return found
+}
+
+func (r *Repository) Checkpoints(ctx context.Context, id string) ([]order.Checkpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.orders[id]
+	if !ok {
+		return nil, nil
+	}
+	return append([]order.Checkpoint(nil), e.checkpoints...), nil
+}
+
+func (r *Repository) Audit(ctx context.Context, id string) ([]order.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries, ok := r.audit[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: order_uid=%s", order.ErrNotFound, id)
+	}
+	result := make([]order.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		diff := make(map[string]order.FieldChange, len(entry.Diff))
+		for path, change := range entry.Diff {
+			diff[path] = change
+		}
+		entry.Diff = diff
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+// appendAudit вызывается под блокировкой на запись.
+func (r *Repository) appendAudit(ctx context.Context, id, operation string, diff map[string]order.FieldChange) {
+	if len(diff) == 0 {
+		return
+	}
+	r.nextAuditID++
+	entry := order.AuditEntry{
+		ID:        r.nextAuditID,
+		OrderUID:  id,
+		Operation: operation,
+		Actor:     order.ActorFromContext(ctx),
+		RequestID: logging.RequestIDFromContext(ctx),
+		Diff:      diff,
+		CreatedAt: time.Now(),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		entry.TraceID = sc.TraceID().String()
+	}
+	r.audit[id] = append(r.audit[id], entry)
+}
+
+func sortNewestFirst(orders []order.Order) {
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].DateCreated.Equal(orders[j].DateCreated) {
+			return orders[i].DateCreated.After(orders[j].DateCreated)
+		}
+		return orders[i].OrderUID < orders[j].OrderUID
+	})
+}
+
+// cloneOrder копирует заказ вместе с доставкой, оплатой и товарами, чтобы
+// вызывающий не менял хранимый заказ через указатели.
+func cloneOrder(o order.Order) order.Order {
+	if o.Delivery != nil {
+		delivery := *o.Delivery
+		o.Delivery = &delivery
+	}
+	if o.Payment != nil {
+		payment := *o.Payment
+		o.Payment = &payment
+	}
+	if o.Items != nil {
+		items := make([]*order.Item, 0, len(o.Items))
+		for _, it := range o.Items {
+			if it == nil {
+				continue
+			}
+			item := *it
+			items = append(items, &item)
+		}
+		o.Items = items
+	}
+	if o.Tags != nil {
+		o.Tags = append([]string{}, o.Tags...)
+	}
+	return o
+}
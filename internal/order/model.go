@@ -1,6 +1,7 @@
 package order
 
 import (
+	"task1/internal/money"
 	"time"
 )
 
@@ -16,47 +17,49 @@ type Order struct {
 	SmID              int       `json:"sm_id" db:"sm_id" validate:"required"`
 	DateCreated       time.Time `json:"date_created" db:"date_created" `
 	OofShard          string    `json:"oof_shard" db:"oof_shard" validate:"required"`
+	Status            Status    `json:"status,omitempty" db:"status"` // заполняется бд, во входящих сообщениях его нет
 	Delivery          *Delivery `json:"delivery" validate:"required"`
 	Payment           *Payment  `json:"payment" validate:"required"`
 	Items             []*Item   `json:"items" validate:"required"`
+	Tags              []string  `json:"tags,omitempty" db:"tags"` // бизнес-правила уровня tag, которые нарушил заказ
 }
 
 type Delivery struct {
 	DeliveryID string `json:"delivery_id" db:"delivery_id"`
-	Name       string `json:"name" db:"name" validate:"required"`
-	Phone      string `json:"phone" db:"phone" validate:"required"`
+	Name       string `json:"name" db:"name" validate:"required" pii:"partial"`
+	Phone      string `json:"phone" db:"phone" validate:"required" pii:"partial"`
 	Zip        string `json:"zip" db:"zip" validate:"required"`
 	City       string `json:"city" db:"city" validate:"required"`
-	Address    string `json:"address" db:"address" validate:"required"`
+	Address    string `json:"address" db:"address" validate:"required" pii:"drop"`
 	Region     string `json:"region" db:"region" validate:"required"`
-	Email      string `json:"email" db:"email" validate:"required"`
+	Email      string `json:"email" db:"email" validate:"required" pii:"partial"`
 }
 
 type Payment struct {
-	PaymentID    string `json:"payment_id" db:"payment_id"`
-	Transaction  string `json:"transaction" db:"transaction" validate:"required"`
-	RequestID    string `json:"request_id" db:"request_id" `
-	Currency     string `json:"currency" db:"currency" validate:"required"`
-	Provider     string `json:"provider" db:"provider" validate:"required"`
-	Amount       int    `json:"amount" db:"amount" validate:"required"`
-	PaymentDT    int    `json:"payment_dt" db:"payment_dt" validate:"required"`
-	Bank         string `json:"bank" db:"bank" validate:"required"`
-	DeliveryCost int    `json:"delivery_cost" db:"delivery_cost" validate:"required"`
-	GoodsTotal   int    `json:"goods_total" db:"goods_total" validate:"required"`
-	CustomFee    int    `json:"custom_fee" db:"custom_fee" `
+	PaymentID    string      `json:"payment_id" db:"payment_id"`
+	Transaction  string      `json:"transaction" db:"transaction" validate:"required"`
+	RequestID    string      `json:"request_id" db:"request_id" `
+	Currency     string      `json:"currency" db:"currency" validate:"required"`
+	Provider     string      `json:"provider" db:"provider" validate:"required"`
+	Amount       money.Money `json:"amount" db:"amount" validate:"required"`
+	PaymentDT    int         `json:"payment_dt" db:"payment_dt" validate:"required"`
+	Bank         string      `json:"bank" db:"bank" validate:"required"`
+	DeliveryCost money.Money `json:"delivery_cost" db:"delivery_cost" validate:"required"`
+	GoodsTotal   money.Money `json:"goods_total" db:"goods_total" validate:"required"`
+	CustomFee    money.Money `json:"custom_fee" db:"custom_fee" `
 }
 
 type Item struct {
-	ItemID      string `json:"item_id" db:"item_id"`
-	ChrtID      int    `json:"chrt_id" db:"chrt_id" validate:"required"`
-	TrackNumber string `json:"track_number" db:"track_number" validate:"required"`
-	Price       int    `json:"price" db:"price" validate:"required"`
-	Rid         string `json:"rid" db:"rid" validate:"required"`
-	Name        string `json:"name" db:"name" validate:"required"`
-	Sale        int    `json:"sale" db:"sale"`
-	Size        string `json:"size" db:"size" validate:"required"`
-	TotalPrice  int    `json:"total_price" db:"total_price" validate:"required"`
-	NmID        int    `json:"nm_id" db:"nm_id" validate:"required"`
-	Brand       string `json:"brand" db:"brand" validate:"required"`
-	Status      int    `json:"status" db:"status" validate:"required"`
+	ItemID      string      `json:"item_id" db:"item_id"`
+	ChrtID      int         `json:"chrt_id" db:"chrt_id" validate:"required"`
+	TrackNumber string      `json:"track_number" db:"track_number" validate:"required"`
+	Price       money.Money `json:"price" db:"price" validate:"required"`
+	Rid         string      `json:"rid" db:"rid" validate:"required"`
+	Name        string      `json:"name" db:"name" validate:"required"`
+	Sale        int         `json:"sale" db:"sale"`
+	Size        string      `json:"size" db:"size" validate:"required"`
+	TotalPrice  money.Money `json:"total_price" db:"total_price" validate:"required"`
+	NmID        int         `json:"nm_id" db:"nm_id" validate:"required"`
+	Brand       string      `json:"brand" db:"brand" validate:"required"`
+	Status      int         `json:"status" db:"status" validate:"required"`
 }
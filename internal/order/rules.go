@@ -0,0 +1,266 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"task1/internal/money"
+
+	"golang.org/x/text/language"
+)
+
+// Severity определяет, что делать с заказом, нарушившим бизнес-правило.
+type Severity string
+
+const (
+	// SeverityReject — заказ не принимается.
+	SeverityReject Severity = "reject"
+	// SeverityWarn — заказ принимается, нарушение пишется в лог.
+	SeverityWarn Severity = "warn"
+	// SeverityTag — заказ принимается и помечается тегом с именем правила.
+	SeverityTag Severity = "tag"
+)
+
+// Имена бизнес-правил; по ним задаются уровни в конфиге.
+const (
+	RuleGoodsTotal      = "goods_total"
+	RuleAmountTotal     = "amount_total"
+	RuleItemTotalPrice  = "item_total_price"
+	RuleItemTrackNumber = "item_track_number"
+	RuleCurrencyCode    = "currency_code"
+	RuleLocaleCode      = "locale_code"
+)
+
+// RequiredRule — имя правила для нарушений тегов validate; они всегда reject.
+const RequiredRule = "required"
+
+// Violation — одно нарушение: правило, поле в JSON-нотации и уровень.
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// ruleCheck сообщает о нарушениях через report; поле и текст — на усмотрение правила.
+type ruleCheck func(o Order, report func(field, message string))
+
+type rule struct {
+	name     string
+	severity Severity
+	check    ruleCheck
+}
+
+var defaultSeverities = map[string]Severity{
+	RuleGoodsTotal:      SeverityReject,
+	RuleAmountTotal:     SeverityReject,
+	RuleItemTotalPrice:  SeverityReject,
+	RuleItemTrackNumber: SeverityWarn,
+	RuleCurrencyCode:    SeverityReject,
+	RuleLocaleCode:      SeverityWarn,
+}
+
+var ruleChecks = map[string]ruleCheck{
+	RuleGoodsTotal:      checkGoodsTotal,
+	RuleAmountTotal:     checkAmountTotal,
+	RuleItemTotalPrice:  checkItemTotalPrice,
+	RuleItemTrackNumber: checkItemTrackNumber,
+	RuleCurrencyCode:    checkCurrencyCode,
+	RuleLocaleCode:      checkLocaleCode,
+}
+
+// Rules — набор бизнес-правил с настроенными уровнями. Безопасен для
+// конкурентного использования.
+type Rules struct {
+	rules []rule
+}
+
+// NewRules собирает правила; severities переопределяет уровни по умолчанию,
+// неизвестные имена правил и уровней — ошибка конфигурации.
+func NewRules(severities map[string]string) (*Rules, error) {
+	levels := make(map[string]Severity, len(defaultSeverities))
+	for name, severity := range defaultSeverities {
+		levels[name] = severity
+	}
+	for name, value := range severities {
+		if _, ok := ruleChecks[name]; !ok {
+			return nil, fmt.Errorf("неизвестное правило %q", name)
+		}
+		switch severity := Severity(value); severity {
+		case SeverityReject, SeverityWarn, SeverityTag:
+			levels[name] = severity
+		default:
+			return nil, fmt.Errorf("правило %q: неизвестный уровень %q", name, value)
+		}
+	}
+	names := make([]string, 0, len(ruleChecks))
+	for name := range ruleChecks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	r := &Rules{rules: make([]rule, 0, len(names))}
+	for _, name := range names {
+		r.rules = append(r.rules, rule{name: name, severity: levels[name], check: ruleChecks[name]})
+	}
+	return r, nil
+}
+
+// DefaultRules — правила с уровнями по умолчанию.
+func DefaultRules() *Rules {
+	r, _ := NewRules(nil)
+	return r
+}
+
+// Check проверяет теги validate и бизнес-правила. Бизнес-правила
+// запускаются только для структурно корректного заказа.
+func (r *Rules) Check(o Order) Report {
+	var report Report
+	if err := o.Validate(); err != nil {
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			report.Violations = append(report.Violations, Violation{Rule: RequiredRule, Severity: SeverityReject, Message: err.Error()})
+			return report
+		}
+		for _, f := range verr.Fields {
+			report.Violations = append(report.Violations, Violation{Rule: f.Rule, Field: f.Field, Severity: SeverityReject})
+		}
+		return report
+	}
+	for _, rl := range r.rules {
+		rl.check(o, func(field, message string) {
+			report.Violations = append(report.Violations, Violation{Rule: rl.name, Field: field, Severity: rl.severity, Message: message})
+		})
+	}
+	return report
+}
+
+// Report — результат проверки заказа.
+type Report struct {
+	Violations []Violation `json:"violations"`
+}
+
+// Rejected сообщает, есть ли нарушения уровня reject.
+func (r Report) Rejected() bool {
+	return slices.ContainsFunc(r.Violations, func(v Violation) bool { return v.Severity == SeverityReject })
+}
+
+// Err возвращает *ValidationError с нарушениями уровня reject или nil.
+func (r Report) Err() error {
+	var fields []FieldError
+	for _, v := range r.Violations {
+		if v.Severity == SeverityReject {
+			fields = append(fields, FieldError{Field: v.Field, Rule: v.Rule})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// Warnings — нарушения уровня warn.
+func (r Report) Warnings() []Violation {
+	var warnings []Violation
+	for _, v := range r.Violations {
+		if v.Severity == SeverityWarn {
+			warnings = append(warnings, v)
+		}
+	}
+	return warnings
+}
+
+// Tags — имена правил уровня tag, без повторов.
+func (r Report) Tags() []string {
+	var tags []string
+	for _, v := range r.Violations {
+		if v.Severity == SeverityTag && !slices.Contains(tags, v.Rule) {
+			tags = append(tags, v.Rule)
+		}
+	}
+	return tags
+}
+
+func checkGoodsTotal(o Order, report func(field, message string)) {
+	sum := money.New(0, money.Currency(o.Payment.Currency))
+	for _, item := range o.Items {
+		var err error
+		if sum, err = sum.Add(item.TotalPrice); err != nil {
+			report("payment.goods_total", err.Error())
+			return
+		}
+	}
+	if !sum.Equal(o.Payment.GoodsTotal) {
+		report("payment.goods_total", fmt.Sprintf("ожидалось %s по сумме total_price товаров", sum))
+	}
+}
+
+func checkAmountTotal(o Order, report func(field, message string)) {
+	p := o.Payment
+	expected, err := p.GoodsTotal.Add(p.DeliveryCost)
+	if err == nil {
+		expected, err = expected.Add(p.CustomFee)
+	}
+	if err != nil {
+		report("payment.amount", err.Error())
+		return
+	}
+	if !expected.Equal(p.Amount) {
+		report("payment.amount", fmt.Sprintf("ожидалось %s = goods_total + delivery_cost + custom_fee", expected))
+	}
+}
+
+// checkItemTotalPrice сверяет total_price с price за вычетом sale процентов.
+// Расхождение меньше одной основной единицы валюты допустимо: продавцы
+// округляют цену со скидкой до целых.
+func checkItemTotalPrice(o Order, report func(field, message string)) {
+	unit, _ := money.Parse("100", money.Currency(o.Payment.Currency))
+	for i, item := range o.Items {
+		if item.Sale < 0 || item.Sale > 100 {
+			report(itemPath(i, "sale"), "скидка должна быть от 0 до 100")
+			continue
+		}
+		// сравниваем в сотых долях, чтобы не делить
+		expected, err := item.Price.Mul(int64(100 - item.Sale))
+		var actual, diff money.Money
+		if err == nil {
+			actual, err = item.TotalPrice.Mul(100)
+		}
+		if err == nil {
+			diff, err = actual.Sub(expected)
+		}
+		if err == nil && diff.Sign() < 0 {
+			diff, err = diff.Mul(-1)
+		}
+		var cmp int
+		if err == nil {
+			cmp, err = diff.Cmp(unit)
+		}
+		if err != nil {
+			report(itemPath(i, "total_price"), err.Error())
+			continue
+		}
+		if cmp >= 0 {
+			report(itemPath(i, "total_price"), fmt.Sprintf("не соответствует price %s со скидкой %d%%", item.Price, item.Sale))
+		}
+	}
+}
+
+func checkItemTrackNumber(o Order, report func(field, message string)) {
+	for i, item := range o.Items {
+		if item.TrackNumber != o.TrackNumber {
+			report(itemPath(i, "track_number"), "не совпадает с track_number заказа")
+		}
+	}
+}
+
+func checkCurrencyCode(o Order, report func(field, message string)) {
+	if !money.Currency(o.Payment.Currency).Known() {
+		report("payment.currency", "неизвестный код ISO 4217")
+	}
+}
+
+func checkLocaleCode(o Order, report func(field, message string)) {
+	if _, err := language.Parse(o.Locale); err != nil {
+		report("locale", "некорректный языковой тег BCP 47")
+	}
+}
@@ -0,0 +1,153 @@
+package order
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"task1/internal/money"
+)
+
+// validOrder — заказ, который проходит все правила. Суммы товаров
+// пересчитываются в goods_total и amount, если тест их поменял.
+func validOrder(edit func(o *Order)) Order {
+	o := Order{
+		TrackNumber: "TRACK", Entry: "WBIL", Locale: "en", CustomerID: "test", DeliveryService: "meest",
+		ShardKey: "9", SmID: 99, OofShard: "1",
+		Delivery: &Delivery{Name: "Test", Phone: "+9720000000", Zip: "2639809", City: "City", Address: "Street",
+			Region: "Region", Email: "t@example.com"},
+		Payment: &Payment{Transaction: "tx", Currency: "USD", Provider: "wbpay", PaymentDT: 1637907727, Bank: "alpha",
+			DeliveryCost: money.New(150000, "USD"), CustomFee: money.New(0, "USD")},
+		Items: []*Item{{ChrtID: 1, TrackNumber: "TRACK", Price: money.New(31700, "USD"), Rid: "rid", Name: "Item",
+			Size: "0", TotalPrice: money.New(31700, "USD"), NmID: 1, Brand: "Brand", Status: 202}},
+	}
+	if edit != nil {
+		edit(&o)
+	}
+	goods := money.New(0, money.Currency(o.Payment.Currency))
+	for _, item := range o.Items {
+		goods, _ = goods.Add(item.TotalPrice)
+	}
+	if o.Payment.GoodsTotal.IsZero() {
+		o.Payment.GoodsTotal = goods
+	}
+	if o.Payment.Amount.IsZero() {
+		o.Payment.Amount, _ = o.Payment.GoodsTotal.Add(o.Payment.DeliveryCost)
+	}
+	return o
+}
+
+func TestRulesCheck(t *testing.T) {
+	rules := DefaultRules()
+	tests := []struct {
+		name  string
+		order Order
+		want  []Violation
+	}{
+		{"корректный заказ", validOrder(nil), nil},
+		{"goods_total не равен сумме товаров", validOrder(func(o *Order) {
+			o.Payment.GoodsTotal = money.New(31600, "USD")
+			o.Payment.Amount = money.New(181600, "USD")
+		}), []Violation{{Rule: RuleGoodsTotal, Field: "payment.goods_total", Severity: SeverityReject}}},
+		{"amount не равен сумме слагаемых", validOrder(func(o *Order) {
+			o.Payment.Amount = money.New(181701, "USD")
+		}), []Violation{{Rule: RuleAmountTotal, Field: "payment.amount", Severity: SeverityReject}}},
+		{"custom_fee входит в amount", validOrder(func(o *Order) {
+			o.Payment.CustomFee = money.New(100, "USD")
+			o.Payment.Amount = money.New(181800, "USD")
+		}), nil},
+		{"total_price со скидкой", validOrder(func(o *Order) {
+			o.Items[0].Sale = 30
+			o.Items[0].TotalPrice = money.New(22190, "USD")
+		}), nil},
+		{"total_price в пределах единицы", validOrder(func(o *Order) {
+			o.Items[0].Sale = 30
+			o.Items[0].TotalPrice = money.New(22289, "USD")
+		}), nil},
+		{"total_price на единицу больше", validOrder(func(o *Order) {
+			o.Items[0].Sale = 30
+			o.Items[0].TotalPrice = money.New(22290, "USD")
+		}), []Violation{{Rule: RuleItemTotalPrice, Field: "items[0].total_price", Severity: SeverityReject}}},
+		{"total_price на единицу меньше", validOrder(func(o *Order) {
+			o.Items[0].Sale = 30
+			o.Items[0].TotalPrice = money.New(22090, "USD")
+		}), []Violation{{Rule: RuleItemTotalPrice, Field: "items[0].total_price", Severity: SeverityReject}}},
+		{"скидка больше 100", validOrder(func(o *Order) {
+			o.Items[0].Sale = 101
+		}), []Violation{{Rule: RuleItemTotalPrice, Field: "items[0].sale", Severity: SeverityReject}}},
+		{"track_number товара", validOrder(func(o *Order) {
+			o.Items[0].TrackNumber = "OTHER"
+		}), []Violation{{Rule: RuleItemTrackNumber, Field: "items[0].track_number", Severity: SeverityWarn}}},
+		{"неизвестная валюта", validOrder(func(o *Order) {
+			o.Payment.Currency = "XXX"
+			o.Payment.DeliveryCost = money.New(150000, "XXX")
+			o.Payment.CustomFee = money.New(0, "XXX")
+			o.Items[0].Price = money.New(31700, "XXX")
+			o.Items[0].TotalPrice = money.New(31700, "XXX")
+		}), []Violation{{Rule: RuleCurrencyCode, Field: "payment.currency", Severity: SeverityReject}}},
+		{"некорректная локаль", validOrder(func(o *Order) {
+			o.Locale = "not a locale"
+		}), []Violation{{Rule: RuleLocaleCode, Field: "locale", Severity: SeverityWarn}}},
+		{"validate не прошёл, правила не запускаются", validOrder(func(o *Order) {
+			o.TrackNumber = ""
+			o.Locale = "not a locale"
+			o.Payment.Amount = money.New(1, "USD")
+		}), []Violation{{Rule: RequiredRule, Field: "track_number", Severity: SeverityReject}}},
+	}
+	for _, tt := range tests {
+		report := rules.Check(tt.order)
+		got := make([]Violation, 0, len(report.Violations))
+		for _, v := range report.Violations {
+			v.Message = ""
+			got = append(got, v)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: нарушения %+v, want %+v", tt.name, report.Violations, tt.want)
+		}
+	}
+}
+
+func TestRulesSeverities(t *testing.T) {
+	broken := validOrder(func(o *Order) {
+		o.Payment.GoodsTotal = money.New(31600, "USD")
+		o.Payment.Amount = money.New(181600, "USD")
+	})
+	tests := []struct {
+		severity string
+		rejected bool
+		warnings int
+		tags     []string
+	}{
+		{"reject", true, 0, nil},
+		{"warn", false, 1, nil},
+		{"tag", false, 0, []string{RuleGoodsTotal}},
+	}
+	for _, tt := range tests {
+		rules, err := NewRules(map[string]string{RuleGoodsTotal: tt.severity})
+		if err != nil {
+			t.Fatalf("NewRules(%s): %v", tt.severity, err)
+		}
+		report := rules.Check(broken)
+		if report.Rejected() != tt.rejected || (report.Err() != nil) != tt.rejected {
+			t.Errorf("%s: Rejected = %v, Err = %v", tt.severity, report.Rejected(), report.Err())
+		}
+		if tt.rejected && !errors.Is(report.Err(), ErrValidation) {
+			t.Errorf("%s: Err = %v, want ErrValidation", tt.severity, report.Err())
+		}
+		if len(report.Warnings()) != tt.warnings {
+			t.Errorf("%s: Warnings = %v", tt.severity, report.Warnings())
+		}
+		if !slices.Equal(report.Tags(), tt.tags) {
+			t.Errorf("%s: Tags = %v, want %v", tt.severity, report.Tags(), tt.tags)
+		}
+	}
+
+	for name, severities := range map[string]map[string]string{
+		"неизвестное правило": {"no_such_rule": "reject"},
+		"неизвестный уровень": {RuleGoodsTotal: "ignore"},
+	} {
+		if _, err := NewRules(severities); err == nil {
+			t.Errorf("%s: NewRules без ошибки", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Status — этап жизненного цикла заказа. Не путать с Item.Status: это код
+// статуса товара от внешней системы.
+type Status string
+
+const (
+	StatusCreated   Status = "created"
+	StatusPaid      Status = "paid"
+	StatusAssembled Status = "assembled"
+	StatusShipped   Status = "shipped"
+	StatusDelivered Status = "delivered"
+	StatusCancelled Status = "cancelled"
+	StatusReturned  Status = "returned"
+)
+
+// transitions — допустимые переходы. Отменить можно до отгрузки,
+// вернуть — только доставленный заказ.
+var transitions = map[Status][]Status{
+	StatusCreated:   {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusAssembled, StatusCancelled},
+	StatusAssembled: {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusReturned},
+	StatusCancelled: {},
+	StatusReturned:  {},
+}
+
+// ErrInvalidTransition — переход не разрешён из текущего статуса; это
+// разновидность ErrConflict.
+var ErrInvalidTransition = fmt.Errorf("%w: invalid status transition", ErrConflict)
+
+// ParseStatus проверяет, что s — известный статус.
+func ParseStatus(s string) (Status, error) {
+	status := Status(s)
+	if _, ok := transitions[status]; !ok {
+		return "", &ValidationError{Fields: []FieldError{{Field: "status", Rule: "oneof"}}}
+	}
+	return status, nil
+}
+
+// CanTransition сообщает, можно ли перевести заказ из from в to.
+func (from Status) CanTransition(to Status) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// Reachable сообщает, можно ли дойти из from в to одним или несколькими
+// переходами. Нужен для событий, пришедших не по порядку: assembled может
+// прийти раньше paid.
+func (from Status) Reachable(to Status) bool {
+	seen := map[Status]bool{from: true}
+	queue := []Status{from}
+	for len(queue) > 0 {
+		for _, next := range transitions[queue[0]] {
+			if next == to {
+				return true
+			}
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+		queue = queue[1:]
+	}
+	return false
+}
+
+// Final — из статуса нет переходов.
+func (s Status) Final() bool {
+	return len(transitions[s]) == 0
+}
+
+// CheckTransition возвращает ErrInvalidTransition с подробностями, если
+// переход запрещён.
+func CheckTransition(from, to Status) error {
+	if !from.CanTransition(to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// StatusChange — запись истории статусов. From пуст у первой записи,
+// сделанной при создании заказа.
+type StatusChange struct {
+	OrderUID  string    `json:"order_uid"`
+	From      Status    `json:"from,omitempty"`
+	To        Status    `json:"to"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ActorSystem — автор изменений, если в контексте никто не указан.
+const ActorSystem = "system"
+
+type actorKey struct{}
+
+// WithActor запоминает в контексте, кто меняет заказ, например
+// "kafka:my-topic" или "api:support-tool". Репозиторий пишет его в историю.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext возвращает автора изменений или ActorSystem.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
@@ -1,13 +1,45 @@
 package order
 
-
-import(
+import (
 	"context"
+	"time"
 )
 
 type Repository interface {
 	Save(ctx context.Context, ord Order) (string, error)
-	FindAll(ctx context.Context) ([]Order,error)
-	FindById(ctx context.Context,id string) (Order,error)
-	
+	SaveBatch(ctx context.Context, orders []Order) ([]string, error)
+	FindAll(ctx context.Context) ([]Order, error)
+	FindById(ctx context.Context, id string) (Order, error)
+	FindByIDs(ctx context.Context, ids []string) ([]Order, error)
+	Search(ctx context.Context, filter SearchFilter) ([]Order, error)
+	// Stream отдаёт заказы по фильтру по одному, не загружая выборку целиком.
+	Stream(ctx context.Context, filter SearchFilter, fn func(Order) error) error
+	// Transition атомарно переводит заказ в статус to и пишет запись в историю.
+	// Запрещённый переход — ErrInvalidTransition.
+	Transition(ctx context.Context, id string, to Status, reason string) (StatusChange, error)
+	// StatusHistory возвращает историю статусов по времени изменения.
+	StatusHistory(ctx context.Context, id string) ([]StatusChange, error)
+	// ApplyEvent применяет событие статусов одной транзакцией. Части события
+	// старше уже применённых пропускаются.
+	ApplyEvent(ctx context.Context, event StatusEvent) (EventResult, error)
+	// Checkpoints возвращает точки маршрута доставки по времени события.
+	Checkpoints(ctx context.Context, id string) ([]Checkpoint, error)
+	// Audit возвращает журнал изменений заказа в порядке записи.
+	Audit(ctx context.Context, id string) ([]AuditEntry, error)
+}
+
+// SearchFilter задаёт условия поиска; пустые поля не ограничивают выборку.
+type SearchFilter struct {
+	CustomerID      string
+	DeliveryService string
+	TrackNumber     string
+	CreatedFrom     time.Time
+	CreatedTo       time.Time
+	Limit           int
+	Offset          int
 }
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 1000
+)
@@ -0,0 +1,40 @@
+package order
+
+import (
+	"reflect"
+	"strings"
+	"task1/internal/money"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate кэширует разобранные теги структур; безопасен для конкурентного использования.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// в ошибках поля называются так же, как в JSON
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// required для сумм означает «не ноль»
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		if m := field.Interface().(money.Money); !m.IsZero() {
+			return m.Decimal()
+		}
+		return nil
+	}, money.Money{})
+	return v
+}
+
+// Validate проверяет заказ по тегам validate модели; ошибка — *ValidationError.
+func (o Order) Validate() error {
+	if err := validate.Struct(o); err != nil {
+		return newValidationError(err)
+	}
+	return nil
+}
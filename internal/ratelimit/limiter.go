@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"task1/internal/auth"
+	"task1/internal/config"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	idleTTL       = 10 * time.Minute
+	sweepInterval = time.Minute
+)
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter ограничивает частоту запросов token bucket'ом на пару (маршрут, клиент).
+// Клиент определяется по аутентифицированному субъекту, а без него — по IP.
+// До аутентификации действует отдельный лимит по IP (WrapPreAuth), чтобы
+// перебор ключей и токенов тоже упирался в лимит.
+// Нулевой *Limiter ничего не ограничивает.
+type Limiter struct {
+	defaultLimit   config.Limit
+	preAuth        config.Limit
+	routes         map[string]config.Limit
+	trustForwarded bool
+	logger         *slog.Logger
+	mu             sync.Mutex
+	buckets        map[string]*bucket
+	lastSweep      time.Time
+}
+
+func New(cfg config.RateLimit, logger *slog.Logger) *Limiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &Limiter{
+		defaultLimit:   cfg.Default,
+		preAuth:        cfg.PreAuth,
+		routes:         cfg.Routes,
+		trustForwarded: cfg.TrustForwardedFor,
+		logger:         logger,
+		buckets:        make(map[string]*bucket),
+		lastSweep:      time.Now(),
+	}
+}
+
+// Wrap ограничивает запросы по маршруту и клиенту; ставится после
+// аутентификации, чтобы клиентом был субъект.
+func (l *Limiter) Wrap(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return l.limit(next, func(r *http.Request) (string, string, config.Limit) {
+		route, limit := l.limitFor(r.URL.Path)
+		client := l.clientKey(r)
+		return route + "|" + client, client, limit
+	})
+}
+
+// WrapPreAuth ограничивает все запросы с одного IP лимитом PreAuth; ставится
+// перед аутентификацией. Нулевой RPS — без ограничения.
+func (l *Limiter) WrapPreAuth(next http.Handler) http.Handler {
+	if l == nil || l.preAuth.RPS <= 0 {
+		return next
+	}
+	return l.limit(next, func(r *http.Request) (string, string, config.Limit) {
+		client := l.ipKey(r)
+		return "pre-auth|" + client, client, l.preAuth
+	})
+}
+
+// limit пропускает запрос, если в bucket'е ключа есть токен; key возвращает
+// ключ bucket'а, клиента для лога и лимит.
+func (l *Limiter) limit(next http.Handler, key func(r *http.Request) (string, string, config.Limit)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucketKey, client, limit := key(r)
+		if limit.RPS <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reservation := l.bucket(bucketKey, limit).Reserve()
+		delay := reservation.Delay()
+		if !reservation.OK() || delay > 0 {
+			reservation.Cancel()
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			if !reservation.OK() || retryAfter < 1 {
+				retryAfter = 1
+			}
+			l.logger.Info("Превышен лимит запросов", "client", client, "path", r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *Limiter) limitFor(path string) (string, config.Limit) {
+	best := ""
+	for route := range l.routes {
+		if matchRoute(route, path) && len(route) > len(best) {
+			best = route
+		}
+	}
+	if best == "" {
+		return "*", l.defaultLimit
+	}
+	return best, l.routes[best]
+}
+
+func (l *Limiter) clientKey(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.Subject != "" {
+		return "sub:" + principal.Subject
+	}
+	return l.ipKey(r)
+}
+
+func (l *Limiter) ipKey(r *http.Request) string {
+	if l.trustForwarded {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return "ip:" + strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func (l *Limiter) bucket(key string, limit config.Limit) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) > idleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(limit.RPS))
+		}
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.RPS), burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter
+}
+
+func matchRoute(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(path, pattern)
+	}
+	return pattern == path
+}
@@ -0,0 +1,45 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	StrategyNone    = "none"
+	StrategyPartial = "partial"
+	StrategyHash    = "hash"
+	StrategyDrop    = "drop"
+)
+
+func mask(strategy, value string, salt []byte) string {
+	if value == "" {
+		return value
+	}
+	switch strategy {
+	case StrategyPartial:
+		return maskPartial(value)
+	case StrategyHash:
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte(value))
+		return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	case StrategyDrop:
+		return ""
+	}
+	return value
+}
+
+// maskPartial оставляет первый символ и два последних, у email — ещё и домен.
+func maskPartial(value string) string {
+	if local, domain, ok := strings.Cut(value, "@"); ok {
+		return maskPartial(local) + "@" + domain
+	}
+	runes := []rune(value)
+	if len(runes) < 6 {
+		return strings.Repeat("*", utf8.RuneCountInString(value))
+	}
+	return string(runes[0]) + strings.Repeat("*", len(runes)-3) + string(runes[len(runes)-2:])
+}
@@ -0,0 +1,220 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"task1/internal/config"
+)
+
+// Redactor маскирует строковые поля, помеченные тегом pii:"<strategy>".
+// Стратегию можно переопределить в конфиге по json-пути поля, например "delivery.phone".
+type Redactor struct {
+	overrides        map[string]string
+	salt             []byte
+	unmaskPermission string
+	piiTypes         sync.Map
+}
+
+func New(cfg config.Redaction) (*Redactor, error) {
+	for path, strategy := range cfg.Fields {
+		switch strategy {
+		case StrategyNone, StrategyPartial, StrategyHash, StrategyDrop:
+		default:
+			return nil, fmt.Errorf("неизвестная стратегия маскирования %q для поля %s", strategy, path)
+		}
+	}
+	return &Redactor{
+		overrides:        cfg.Fields,
+		salt:             []byte(cfg.HashSalt),
+		unmaskPermission: cfg.UnmaskPermission,
+	}, nil
+}
+
+func (r *Redactor) UnmaskPermission() string {
+	return r.unmaskPermission
+}
+
+// Redact возвращает копию v с замаскированными PII-полями, сам v не меняется.
+func Redact[T any](r *Redactor, v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	r.copyValue(dst, src, "")
+	return dst.Interface().(T)
+}
+
+// RedactPath маскирует значение поля типа T по json-пути, например
+// "delivery.phone" или "items[0].name"; индексы в скобках не учитываются.
+// Значения полей без PII и пути, которых нет в T, возвращаются как есть.
+func RedactPath[T any](r *Redactor, path string, value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	field, fieldPath, ok := resolvePath(reflect.TypeFor[T](), path)
+	if !ok || field.Type.Kind() != reflect.String {
+		return value
+	}
+	if strategy := r.strategy(field, fieldPath); strategy != "" {
+		return mask(strategy, s, r.salt)
+	}
+	return value
+}
+
+func resolvePath(t reflect.Type, path string) (reflect.StructField, string, bool) {
+	var (
+		field     reflect.StructField
+		fieldPath string
+	)
+	for _, segment := range strings.Split(path, ".") {
+		name, _, _ := strings.Cut(segment, "[")
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return field, "", false
+		}
+		f, ok := findField(t, name)
+		if !ok {
+			return field, "", false
+		}
+		field, t = f, f.Type
+		fieldPath = joinPath(fieldPath, name)
+	}
+	return field, fieldPath, true
+}
+
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() && fieldName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// ReplaceAttr подключается в slog.HandlerOptions, чтобы PII не попадали в логи.
+// Значения с PII-полями маскируются и пишутся как JSON.
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	v := a.Value.Any()
+	if v == nil || !r.hasPII(reflect.TypeOf(v)) {
+		return a
+	}
+	src := reflect.ValueOf(v)
+	dst := reflect.New(src.Type()).Elem()
+	r.copyValue(dst, src, "")
+	data, err := json.Marshal(dst.Interface())
+	if err != nil {
+		return slog.String(a.Key, "<redacted>")
+	}
+	return slog.Any(a.Key, jsonValue(data))
+}
+
+func (r *Redactor) copyValue(dst, src reflect.Value, path string) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		r.copyValue(dst.Elem(), src.Elem(), path)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			r.copyValue(dst.Index(i), src.Index(i), path)
+		}
+	case reflect.Struct:
+		dst.Set(src)
+		t := src.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := joinPath(path, fieldName(field))
+			if strategy := r.strategy(field, fieldPath); strategy != "" && field.Type.Kind() == reflect.String {
+				dst.Field(i).SetString(mask(strategy, src.Field(i).String(), r.salt))
+				continue
+			}
+			r.copyValue(dst.Field(i), src.Field(i), fieldPath)
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+func (r *Redactor) strategy(field reflect.StructField, path string) string {
+	strategy, ok := r.overrides[path]
+	if !ok {
+		strategy = field.Tag.Get("pii")
+	}
+	if strategy == StrategyNone {
+		return ""
+	}
+	return strategy
+}
+
+func (r *Redactor) hasPII(t reflect.Type) bool {
+	if cached, ok := r.piiTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	found := r.typeHasPII(t, "", 0)
+	r.piiTypes.Store(t, found)
+	return found
+}
+
+func (r *Redactor) typeHasPII(t reflect.Type, path string, depth int) bool {
+	if depth > 8 {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return r.typeHasPII(t.Elem(), path, depth+1)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := joinPath(path, fieldName(field))
+			if r.strategy(field, fieldPath) != "" || r.typeHasPII(field.Type, fieldPath, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+type jsonValue []byte
+
+func (v jsonValue) MarshalJSON() ([]byte, error) {
+	return v, nil
+}
+
+func (v jsonValue) MarshalText() ([]byte, error) {
+	return v, nil
+}
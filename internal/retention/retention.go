@@ -0,0 +1,196 @@
+// Package retention обслуживает месячные секции заказов: создаёт будущие и
+// выгружает в архив и удаляет устаревшие.
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"task1/internal/config"
+	"task1/internal/export"
+	"task1/internal/metrics"
+	"task1/internal/order"
+	"task1/internal/order/db"
+	"time"
+)
+
+type Job struct {
+	cfg        config.Retention
+	partitions *db.Partitions
+	logger     *slog.Logger
+}
+
+func NewJob(cfg config.Retention, partitions *db.Partitions, logger *slog.Logger) *Job {
+	return &Job{
+		cfg:        cfg,
+		partitions: partitions,
+		logger:     logger,
+	}
+}
+
+// Run выполняет задачу сразу и затем раз в Interval до отмены ctx.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := j.RunOnce(ctx, time.Now()); err != nil {
+			j.logger.ErrorContext(ctx, "Ошибка обслуживания секций заказов", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce создаёт секции текущего и следующих месяцев, а секции, целиком
+// старше MaxAge, отсоединяет, выгружает в архив и удаляет. Секция удаляется
+// только после того, как архив записан на диск; отсоединённые секции,
+// оставшиеся после сбоя прошлого запуска, дорабатываются первыми. Строки
+// старых месяцев из секции по умолчанию сначала переносятся в секцию месяца.
+func (j *Job) RunOnce(ctx context.Context, now time.Time) error {
+	current := monthOf(now)
+	for i := 0; i <= j.cfg.PremakeMonths; i++ {
+		month := current.AddDate(0, i, 0)
+		if err := j.partitions.Create(ctx, month); err != nil {
+			// без будущей секции заказы попадут в секцию по умолчанию и
+			// переедут при её создании, архивацию это не останавливает
+			j.logger.ErrorContext(ctx, "Не удалось создать секцию заказов", "error", err, "partition", db.PartitionName(month))
+		}
+	}
+	if !j.cfg.Enabled {
+		return nil
+	}
+	detached, err := j.partitions.Detached(ctx)
+	if err != nil {
+		return err
+	}
+	for _, month := range detached {
+		if err := j.retire(ctx, month); err != nil {
+			return err
+		}
+	}
+
+	cutoff := now.Add(-j.cfg.MaxAge)
+	leftovers, err := j.partitions.DefaultMonths(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	for _, month := range leftovers {
+		if err := j.partitions.Create(ctx, month); err != nil {
+			return fmt.Errorf("перенос секции %s из секции по умолчанию: %w", db.PartitionName(month), err)
+		}
+	}
+	months, err := j.partitions.Months(ctx)
+	if err != nil {
+		return err
+	}
+	for _, month := range months {
+		if month.AddDate(0, 1, 0).After(cutoff) {
+			break
+		}
+		if err := j.partitions.Detach(ctx, month); err != nil {
+			metrics.RetentionPartitions.WithLabelValues("detach_error").Inc()
+			return fmt.Errorf("отсоединение секции %s: %w", db.PartitionName(month), err)
+		}
+		if err := j.retire(ctx, month); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retire выгружает отсоединённые секции месяца в архив и удаляет их.
+func (j *Job) retire(ctx context.Context, month time.Time) error {
+	logger := j.logger.With("partition", db.PartitionName(month))
+	path, archived, err := j.archive(ctx, month)
+	if err != nil {
+		metrics.RetentionPartitions.WithLabelValues("archive_error").Inc()
+		return fmt.Errorf("архивация секции %s: %w", db.PartitionName(month), err)
+	}
+	logger.InfoContext(ctx, "Секция заказов выгружена в архив", "path", path, "orders", archived)
+	if err := j.partitions.Drop(ctx, month); err != nil {
+		metrics.RetentionPartitions.WithLabelValues("drop_error").Inc()
+		return fmt.Errorf("удаление секции %s: %w", db.PartitionName(month), err)
+	}
+	metrics.RetentionPartitions.WithLabelValues("archived").Inc()
+	logger.InfoContext(ctx, "Секция заказов удалена")
+	return nil
+}
+
+// archive пишет заказы отсоединённых секций месяца в
+// ArchiveDir/orders_y2024m01.ndjson.gz в формате выгрузки export. Файл
+// появляется под своим именем только целиком. Если архив месяца уже есть
+// (месяц дозаполнился из секции по умолчанию или прошлый запуск упал до
+// удаления), новый получает суффикс: orders_y2024m01-2.ndjson.gz.
+func (j *Job) archive(ctx context.Context, month time.Time) (path string, archived int, err error) {
+	if err := os.MkdirAll(j.cfg.ArchiveDir, 0o755); err != nil {
+		return "", 0, err
+	}
+	path, err = archivePath(j.cfg.ArchiveDir, "orders_"+db.PartitionName(month))
+	if err != nil {
+		return "", 0, err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	writer, err := export.NewWriter(export.FormatNDJSON, gz)
+	if err != nil {
+		return "", 0, err
+	}
+	err = j.partitions.Stream(ctx, month, func(ord order.Order) error {
+		archived++
+		return writer.Write(ord)
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	if err = writer.Close(); err != nil {
+		return "", 0, err
+	}
+	if err = gz.Close(); err != nil {
+		return "", 0, err
+	}
+	if err = f.Sync(); err != nil {
+		return "", 0, err
+	}
+	if err = f.Close(); err != nil {
+		return "", 0, err
+	}
+	return path, archived, os.Rename(tmp, path)
+}
+
+// archivePath возвращает первое свободное имя архива name в dir.
+func archivePath(dir, name string) (string, error) {
+	path := filepath.Join(dir, name+".ndjson.gz")
+	for n := 2; ; n++ {
+		_, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.ndjson.gz", name, n))
+	}
+}
+
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,35 @@
+package serv
+
+import (
+	"encoding/json"
+	"net/http"
+	"task1/internal/logging"
+	"task1/internal/order"
+	"task1/internal/redact"
+)
+
+func (s *Server) getOrderAudit(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), "order_uid", id)
+	entries, err := s.repo.Audit(ctx, id)
+	if err != nil {
+		if httpStatus(err) != http.StatusNotFound {
+			s.logger.ErrorContext(ctx, "Ошибка чтения журнала изменений", "error", err)
+		}
+		writeError(w, err)
+		return
+	}
+	// в diff те же PII, что и в заказе: маскируем их по пути поля
+	if !s.auth.HasPermission(ctx, s.redactor.UnmaskPermission()) {
+		for _, entry := range entries {
+			for path, change := range entry.Diff {
+				entry.Diff[path] = order.FieldChange{
+					Before: redact.RedactPath[order.Order](s.redactor, path, change.Before),
+					After:  redact.RedactPath[order.Order](s.redactor, path, change.After),
+				}
+			}
+		}
+	}
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
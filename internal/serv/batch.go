@@ -0,0 +1,102 @@
+package serv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"task1/internal/order"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type batchGetRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+type batchGetResponse struct {
+	Orders map[string]batchGetResult `json:"orders"`
+}
+
+// batchGetResult содержит либо заказ, либо ошибку по конкретному id.
+type batchGetResult struct {
+	Order *order.Order   `json:"order,omitempty"`
+	Error *batchGetError `json:"error,omitempty"`
+}
+
+type batchGetError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	errBatchInvalidID   = &batchGetError{Code: "invalid_id", Message: "order_uid is not a valid uuid"}
+	errBatchNotFound    = &batchGetError{Code: "not_found", Message: "order not found"}
+	errBatchUnavailable = &batchGetError{Code: "unavailable", Message: "order storage is unavailable"}
+)
+
+func (s *Server) batchGetOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req batchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if len(req.OrderUIDs) == 0 {
+		http.Error(w, "order_uids is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.OrderUIDs) > s.batchMaxIDs {
+		http.Error(w, fmt.Sprintf("at most %d order_uids per request", s.batchMaxIDs), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	resp := batchGetResponse{Orders: make(map[string]batchGetResult, len(req.OrderUIDs))}
+	misses := make([]string, 0, len(req.OrderUIDs))
+	requested := make(map[string]string, len(req.OrderUIDs))
+	_, span := tracer.Start(ctx, "cache.load_batch")
+	for _, id := range req.OrderUIDs {
+		if _, done := resp.Orders[id]; done {
+			continue
+		}
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			resp.Orders[id] = batchGetResult{Error: errBatchInvalidID}
+			continue
+		}
+		if ord, ok := s.cache.Load(id); ok {
+			ord = s.visibleOrder(r, ord)
+			resp.Orders[id] = batchGetResult{Order: &ord}
+			continue
+		}
+		// резервируем ключ, чтобы дубликаты не попали в запрос к бд повторно
+		resp.Orders[id] = batchGetResult{Error: errBatchNotFound}
+		misses = append(misses, id)
+		requested[parsed.String()] = id
+	}
+	span.SetAttributes(attribute.Int("orders.requested", len(resp.Orders)), attribute.Int("cache.misses", len(misses)))
+	span.End()
+
+	if len(misses) > 0 {
+		orders, err := s.repo.FindByIDs(ctx, misses)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Ошибка пакетного чтения заказов из бд", "error", err, "count", len(misses))
+			for _, id := range misses {
+				resp.Orders[id] = batchGetResult{Error: errBatchUnavailable}
+			}
+		}
+		for _, ord := range orders {
+			ord = s.visibleOrder(r, ord)
+			if id, ok := requested[ord.OrderUID]; ok {
+				resp.Orders[id] = batchGetResult{Order: &ord}
+			}
+		}
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
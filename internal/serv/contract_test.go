@@ -0,0 +1,269 @@
+package serv_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"task1/internal/auth"
+	"task1/internal/cache"
+	"task1/internal/config"
+	"task1/internal/money"
+	"task1/internal/order"
+	"task1/internal/order/memory"
+	"task1/internal/redact"
+	"task1/internal/serv"
+	"task1/pkg/orderclient"
+)
+
+// newTestServer поднимает serv.Server на in-memory хранилище с одним заказом.
+// authMiddleware nil — аутентификация выключена.
+func newTestServer(t *testing.T, authMiddleware *auth.Middleware) (*httptest.Server, string) {
+	t.Helper()
+	return newTestServerConfig(t, &config.Config{HTTP: config.HTTP{BatchGetMaxIDs: 100}}, authMiddleware)
+}
+
+func newTestServerConfig(t *testing.T, cfg *config.Config, authMiddleware *auth.Middleware) (*httptest.Server, string) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.NewRepository()
+	id, err := repo.Save(context.Background(), testOrder())
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	redactor, err := redact.New(config.Redaction{UnmaskPermission: "delivery_pii"})
+	if err != nil {
+		t.Fatalf("redact.New: %v", err)
+	}
+	server := serv.NewServer(*cache.NewOrderCache(logger), logger, repo, cfg, authMiddleware, redactor, order.DefaultRules())
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return ts, id
+}
+
+func testOrder() order.Order {
+	return order.Order{
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		OofShard:        "1",
+		Delivery: &order.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: &order.Payment{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay",
+			Amount: money.New(181700, "USD"), PaymentDT: 1637907727, Bank: "alpha",
+			DeliveryCost: money.New(150000, "USD"), GoodsTotal: money.New(31700, "USD"), CustomFee: money.New(0, "USD"),
+		},
+		Items: []*order.Item{{
+			ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: money.New(45300, "USD"),
+			Rid: "ab4219087a764ae0btest", Name: "Mascaras", Sale: 30, Size: "0",
+			TotalPrice: money.New(31700, "USD"), NmID: 2389212, Brand: "Vivienne Sabo", Status: 202,
+		}},
+	}
+}
+
+func TestGetOrderContract(t *testing.T) {
+	ts, id := newTestServer(t, nil)
+	client := orderclient.NewClient(ts.URL, ts.Client())
+	ctx := context.Background()
+
+	got, err := client.GetOrder(ctx, id)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if got.OrderUID != id || got.TrackNumber != "WBILMTESTTRACK" || got.Status != string(order.StatusCreated) {
+		t.Fatalf("GetOrder вернул %+v", got)
+	}
+	if got.Payment == nil || got.Payment.Amount.String() != "1817" || got.Payment.Currency != "USD" {
+		t.Fatalf("оплата %+v", got.Payment)
+	}
+	if len(got.Items) != 1 || got.Items[0].ChrtID != 9934930 {
+		t.Fatalf("товары %+v", got.Items)
+	}
+	// аутентификация выключена: principal нет, и PII маскируются
+	if got.Delivery == nil || got.Delivery.Name == "Test Testov" || got.Delivery.Email == "test@gmail.com" {
+		t.Fatalf("PII не замаскированы: %+v", got.Delivery)
+	}
+
+	if _, err := client.GetOrder(ctx, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, orderclient.ErrNotFound) {
+		t.Fatalf("GetOrder неизвестного заказа: %v", err)
+	}
+	var statusErr *orderclient.StatusError
+	if _, err := client.GetOrder(ctx, ""); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("GetOrder без id: %v", err)
+	}
+}
+
+// TestOpenAPIMatchesHandler сверяет ответ /getOrder со схемой Order из
+// спецификации, которую отдаёт тот же сервер.
+func TestOpenAPIMatchesHandler(t *testing.T) {
+	ts, id := newTestServer(t, nil)
+	client := orderclient.NewClient(ts.URL, ts.Client())
+
+	spec, err := client.GetOpenAPI(context.Background())
+	if err != nil {
+		t.Fatalf("GetOpenAPI: %v", err)
+	}
+	paths, _ := spec["paths"].(map[string]any)
+	for _, path := range []string{"/getOrder", "/api/v1/orders:batchGet", "/api/v1/orders/{id}/status", "/api/v1/orders/{id}/audit"} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("в спецификации нет пути %s", path)
+		}
+	}
+
+	resp, err := ts.Client().Get(ts.URL + "/getOrder?id=" + id)
+	if err != nil {
+		t.Fatalf("GET /getOrder: %v", err)
+	}
+	defer resp.Body.Close()
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("разбор ответа: %v", err)
+	}
+	checkSchema(t, spec, "Order", "", body)
+}
+
+// checkSchema проверяет, что у объекта есть все required поля схемы и нет
+// полей, которых в схеме нет, а значение перечисления входит в enum.
+// Вложенные $ref проверяются рекурсивно.
+func checkSchema(t *testing.T, spec map[string]any, name, path string, value any) {
+	t.Helper()
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	schema, ok := schemas[name].(map[string]any)
+	if !ok {
+		t.Fatalf("в спецификации нет схемы %s", name)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		for _, allowed := range enum {
+			if allowed == value {
+				return
+			}
+		}
+		t.Errorf("%s: значения %v нет в enum схемы %s", path, value, name)
+		return
+	}
+	if schema["type"] != "object" {
+		return
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		t.Errorf("%s: ожидался объект %s, получено %T", path, name, value)
+		return
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+	for _, field := range required {
+		if _, ok := obj[field.(string)]; !ok {
+			t.Errorf("%s: нет обязательного поля %s схемы %s", path, field, name)
+		}
+	}
+	for field, fieldValue := range obj {
+		property, ok := properties[field].(map[string]any)
+		if !ok {
+			t.Errorf("%s: поля %s нет в схеме %s", path, field, name)
+			continue
+		}
+		fieldPath := strings.TrimPrefix(path+"."+field, ".")
+		if ref := schemaRef(property); ref != "" {
+			checkSchema(t, spec, ref, fieldPath, fieldValue)
+		}
+		if items, ok := property["items"].(map[string]any); ok {
+			if ref := schemaRef(items); ref != "" {
+				for _, item := range fieldValue.([]any) {
+					checkSchema(t, spec, ref, fieldPath+"[]", item)
+				}
+			}
+		}
+	}
+}
+
+// schemaRef возвращает имя схемы из $ref или allOf[0].$ref.
+func schemaRef(property map[string]any) string {
+	ref, _ := property["$ref"].(string)
+	if allOf, ok := property["allOf"].([]any); ok && ref == "" && len(allOf) > 0 {
+		ref, _ = allOf[0].(map[string]any)["$ref"].(string)
+	}
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+// TestDocsBundled проверяет, что страница документации и swagger-ui
+// отдаются из бинарника, без внешних CDN.
+func TestDocsBundled(t *testing.T) {
+	ts, _ := newTestServer(t, nil)
+	for _, path := range []string{"/static/docs.html", "/docs", "/docs/swagger-ui/swagger-ui.css", "/docs/swagger-ui/swagger-ui-bundle.js"} {
+		resp, err := ts.Client().Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: статус %d", path, resp.StatusCode)
+		}
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") && strings.Contains(string(body), "://") {
+			t.Errorf("страница документации ссылается на внешние ресурсы")
+		}
+	}
+}
+
+// TestTransitionRequiresPrincipal: при выключенной аутентификации анонимный
+// вызывающий не может менять статус заказа.
+func TestTransitionRequiresPrincipal(t *testing.T) {
+	ts, id := newTestServer(t, nil)
+	client := orderclient.NewClient(ts.URL, ts.Client())
+	_, err := client.TransitionOrderStatus(context.Background(), id, "paid", "test")
+	var statusErr *orderclient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("TransitionOrderStatus без учётных данных: %v", err)
+	}
+}
+
+func TestTransitionWithPermission(t *testing.T) {
+	authMiddleware, err := auth.New(config.Auth{
+		Enabled: true,
+		APIKeys: []config.APIKey{
+			{Name: "support", Key: "support-key", Roles: []string{"support"}},
+			{Name: "analyst", Key: "analyst-key", Roles: []string{"analyst"}},
+		},
+		Roles: map[string]config.Role{
+			"support": {Routes: []string{"/api/v1/orders/"}, Permissions: []string{"order_status"}},
+			"analyst": {Routes: []string{"/api/v1/orders/"}},
+		},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("auth.New: %v", err)
+	}
+	ts, id := newTestServer(t, authMiddleware)
+	client := orderclient.NewClient(ts.URL, ts.Client())
+	ctx := context.Background()
+
+	client.SetAPIKey("analyst-key")
+	_, err = client.TransitionOrderStatus(ctx, id, "paid", "test")
+	var statusErr *orderclient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("TransitionOrderStatus без права order_status: %v", err)
+	}
+
+	client.SetAPIKey("support-key")
+	change, err := client.TransitionOrderStatus(ctx, id, "paid", "test")
+	if err != nil {
+		t.Fatalf("TransitionOrderStatus: %v", err)
+	}
+	if change.To != "paid" || change.Actor != "api:support" {
+		t.Fatalf("переход %+v", change)
+	}
+}
@@ -0,0 +1,51 @@
+package serv
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task1/internal/order"
+)
+
+type errorResponse struct {
+	Error  string             `json:"error"`
+	Fields []order.FieldError `json:"fields,omitempty"`
+}
+
+// httpStatus сопоставляет ошибки order.Err* с HTTP-статусами.
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, order.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, order.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, order.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, order.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError отвечает JSON-ошибкой без деталей драйвера; для валидации
+// перечисляет поля.
+func writeError(w http.ResponseWriter, err error) {
+	status := httpStatus(err)
+	resp := errorResponse{Error: http.StatusText(status)}
+	var verr *order.ValidationError
+	if errors.As(err, &verr) {
+		resp.Error = order.ErrValidation.Error()
+		resp.Fields = verr.Fields
+	} else {
+		for _, sentinel := range []error{order.ErrNotFound, order.ErrInvalidTransition, order.ErrConflict, order.ErrUnavailable} {
+			if errors.Is(err, sentinel) {
+				resp.Error = sentinel.Error()
+				break
+			}
+		}
+	}
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
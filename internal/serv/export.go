@@ -0,0 +1,104 @@
+package serv
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"task1/internal/export"
+	"task1/internal/order"
+	"time"
+)
+
+// exportOrders отдаёт заказы по фильтру файлом в NDJSON, CSV или XLSX.
+// Тело пишется по мере чтения курсора, поэтому ошибка посреди выгрузки
+// видна клиенту только как оборванный ответ.
+func (s *Server) exportOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, "format must be one of ndjson, csv, xlsx", http.StatusBadRequest)
+		return
+	}
+	filter, err := parseExportFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// выгрузка может идти дольше WriteTimeout сервера
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.FileName(time.Now())))
+	w.Header().Set("Vary", "Accept-Encoding")
+	var out io.Writer = w
+	if acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+
+	ctx := r.Context()
+	writer, err := export.NewWriter(format, out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	exported := 0
+	err = s.repo.Stream(ctx, filter, func(ord order.Order) error {
+		exported++
+		return writer.Write(s.visibleOrder(r, ord))
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Выгрузка заказов прервана", "error", err, "format", format, "exported", exported)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		s.logger.ErrorContext(ctx, "Ошибка завершения выгрузки", "error", err, "format", format)
+		return
+	}
+	s.logger.InfoContext(ctx, "Выгрузка заказов завершена", "format", format, "exported", exported)
+}
+
+func parseExportFilter(query url.Values) (order.SearchFilter, error) {
+	filter := order.SearchFilter{
+		CustomerID:      query.Get("customer_id"),
+		DeliveryService: query.Get("delivery_service"),
+		TrackNumber:     query.Get("track_number"),
+	}
+	var err error
+	if v := query.Get("created_from"); v != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("created_from must be RFC3339")
+		}
+	}
+	if v := query.Get("created_to"); v != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("created_to must be RFC3339")
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 0 {
+			return filter, fmt.Errorf("limit must be a non-negative integer")
+		}
+	}
+	return filter, nil
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(name, "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package serv
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLang = "ru"
+
+// catalogs — переводы строк HTML-страниц; ключи одинаковы во всех языках.
+var catalogs = map[string]map[string]string{
+	"ru": {
+		"search.title":       "Поиск заказов",
+		"search.id":          "ID заказа",
+		"search.customer":    "ID покупателя",
+		"search.track":       "Трек-номер",
+		"search.service":     "Служба доставки",
+		"search.submit":      "Найти",
+		"search.empty":       "Заказы не найдены",
+		"search.results":     "Найдено заказов",
+		"order.title":        "Заказ",
+		"order.uid":          "Order UID",
+		"order.track":        "Трек-номер",
+		"order.entry":        "Entry",
+		"order.locale":       "Язык",
+		"order.customer":     "Покупатель",
+		"order.service":      "Служба доставки",
+		"order.created":      "Создан",
+		"order.not_found":    "Заказ не найден",
+		"order.back":         "К поиску",
+		"delivery.title":     "Доставка",
+		"delivery.name":      "Имя",
+		"delivery.phone":     "Телефон",
+		"delivery.address":   "Адрес",
+		"delivery.email":     "Почта",
+		"payment.title":      "Оплата",
+		"payment.amount":     "Сумма",
+		"payment.goods":      "Товары",
+		"payment.delivery":   "Доставка",
+		"payment.fee":        "Пошлина",
+		"payment.provider":   "Провайдер",
+		"payment.bank":       "Банк",
+		"items.title":        "Товары",
+		"items.name":         "Название",
+		"items.brand":        "Бренд",
+		"items.price":        "Цена",
+		"items.sale":         "Скидка",
+		"items.total":        "Итого",
+		"items.article":      "Артикул",
+		"error.unavailable":  "Хранилище заказов недоступно, попробуйте позже",
+		"error.bad_request":  "Некорректные параметры поиска",
+		"lang.switch":        "English",
+		"lang.switch_target": "en",
+	},
+	"en": {
+		"search.title":       "Order search",
+		"search.id":          "Order ID",
+		"search.customer":    "Customer ID",
+		"search.track":       "Track number",
+		"search.service":     "Delivery service",
+		"search.submit":      "Search",
+		"search.empty":       "No orders found",
+		"search.results":     "Orders found",
+		"order.title":        "Order",
+		"order.uid":          "Order UID",
+		"order.track":        "Track number",
+		"order.entry":        "Entry",
+		"order.locale":       "Locale",
+		"order.customer":     "Customer",
+		"order.service":      "Delivery service",
+		"order.created":      "Created",
+		"order.not_found":    "Order not found",
+		"order.back":         "Back to search",
+		"delivery.title":     "Delivery",
+		"delivery.name":      "Name",
+		"delivery.phone":     "Phone",
+		"delivery.address":   "Address",
+		"delivery.email":     "Email",
+		"payment.title":      "Payment",
+		"payment.amount":     "Amount",
+		"payment.goods":      "Goods",
+		"payment.delivery":   "Delivery",
+		"payment.fee":        "Custom fee",
+		"payment.provider":   "Provider",
+		"payment.bank":       "Bank",
+		"items.title":        "Items",
+		"items.name":         "Name",
+		"items.brand":        "Brand",
+		"items.price":        "Price",
+		"items.sale":         "Sale",
+		"items.total":        "Total",
+		"items.article":      "Article",
+		"error.unavailable":  "Order storage is unavailable, try again later",
+		"error.bad_request":  "Invalid search parameters",
+		"lang.switch":        "Русский",
+		"lang.switch_target": "ru",
+	},
+}
+
+var dateLayouts = map[string]string{
+	"ru": "02.01.2006 15:04",
+	"en": "Jan 2, 2006 15:04",
+}
+
+// supportedLang сводит тег вида en-US или RU к языку каталога.
+func supportedLang(tag string) (string, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	base, _, _ = strings.Cut(base, "_")
+	_, ok := catalogs[base]
+	return base, ok
+}
+
+// pickLang выбирает язык страницы: явный ?lang=, затем язык заказа
+// (если он есть), затем Accept-Language, иначе русский.
+func pickLang(r *http.Request, orderLocale string) string {
+	if lang, ok := supportedLang(r.URL.Query().Get("lang")); ok {
+		return lang
+	}
+	if lang, ok := supportedLang(orderLocale); ok {
+		return lang
+	}
+	if lang, ok := acceptLanguage(r.Header.Get("Accept-Language")); ok {
+		return lang
+	}
+	return defaultLang
+}
+
+// acceptLanguage возвращает поддерживаемый язык с наибольшим q.
+func acceptLanguage(header string) (string, bool) {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := supportedLang(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang, true
+}
@@ -0,0 +1,32 @@
+package serv
+
+import (
+	_ "embed"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage — страница Swagger UI по /static/docs.html и /docs; сам
+// swagger-ui встроен в бинарник и отдаётся с /docs/swagger-ui/, внешние CDN
+// не нужны.
+//
+//go:embed web/docs.html
+var docsPage []byte
+
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func (s *Server) getDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
+
+func swaggerUIHandler() http.Handler {
+	return http.StripPrefix("/docs/swagger-ui/", http.FileServer(http.FS(swaggerFiles.FS)))
+}
@@ -0,0 +1,167 @@
+package serv
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"task1/internal/order"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:embed web/templates/*.html web/assets
+var webFS embed.FS
+
+// pageTemplates — по набору шаблонов на страницу, у каждой свои блоки title/content.
+var pageTemplates = map[string]*template.Template{
+	"order":  template.Must(template.ParseFS(webFS, "web/templates/layout.html", "web/templates/order.html")),
+	"search": template.Must(template.ParseFS(webFS, "web/templates/layout.html", "web/templates/search.html")),
+}
+
+// page — общие данные шаблонов: язык и переводы.
+type page struct {
+	Lang      string
+	SwitchURL string
+	Error     string
+}
+
+func newPage(r *http.Request, lang string) page {
+	p := page{Lang: lang}
+	target := p.T("lang.switch_target")
+	u := *r.URL
+	q := u.Query()
+	q.Set("lang", target)
+	u.RawQuery = q.Encode()
+	p.SwitchURL = u.RequestURI()
+	return p
+}
+
+// T возвращает перевод ключа; неизвестный ключ выводится как есть.
+func (p page) T(key string) string {
+	if msg, ok := catalogs[p.Lang][key]; ok {
+		return msg
+	}
+	return key
+}
+
+func (p page) Date(t time.Time) string {
+	return t.Format(dateLayouts[p.Lang])
+}
+
+type orderPage struct {
+	page
+	Order *order.Order
+}
+
+type searchQuery struct {
+	ID              string
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+}
+
+type searchPage struct {
+	page
+	Query    searchQuery
+	Searched bool
+	Orders   []order.Order
+}
+
+func assetsHandler() http.Handler {
+	assets, _ := fs.Sub(webFS, "web/assets")
+	return http.StripPrefix("/assets/", http.FileServer(http.FS(assets)))
+}
+
+func (s *Server) orderPage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := r.Context()
+	ord, found, err := s.lookupOrder(r, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Ошибка поиска заказа в бд", "error", err, "order_uid", id)
+		p := orderPage{page: newPage(r, pickLang(r, ""))}
+		p.Error = "error.unavailable"
+		s.renderPage(w, r, http.StatusServiceUnavailable, "order", p)
+		return
+	}
+	if !found {
+		p := orderPage{page: newPage(r, pickLang(r, ""))}
+		p.Error = "order.not_found"
+		s.renderPage(w, r, http.StatusNotFound, "order", p)
+		return
+	}
+	ord = s.visibleOrder(r, ord)
+	s.renderPage(w, r, http.StatusOK, "order", orderPage{page: newPage(r, pickLang(r, ord.Locale)), Order: &ord})
+}
+
+func (s *Server) searchPage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := searchQuery{
+		ID:              query.Get("id"),
+		CustomerID:      query.Get("customer_id"),
+		TrackNumber:     query.Get("track_number"),
+		DeliveryService: query.Get("delivery_service"),
+	}
+	lang := pickLang(r, "")
+	if q.ID != "" {
+		http.Redirect(w, r, "/orders/"+url.PathEscape(q.ID)+"?lang="+lang, http.StatusSeeOther)
+		return
+	}
+	p := searchPage{page: newPage(r, lang), Query: q}
+	if q.CustomerID == "" && q.TrackNumber == "" && q.DeliveryService == "" {
+		s.renderPage(w, r, http.StatusOK, "search", p)
+		return
+	}
+	p.Searched = true
+	orders, err := s.repo.Search(r.Context(), order.SearchFilter{
+		CustomerID:      q.CustomerID,
+		TrackNumber:     q.TrackNumber,
+		DeliveryService: q.DeliveryService,
+	})
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "Ошибка поиска заказов", "error", err)
+		p.Error = "error.unavailable"
+		s.renderPage(w, r, http.StatusServiceUnavailable, "search", p)
+		return
+	}
+	for i := range orders {
+		orders[i] = s.visibleOrder(r, orders[i])
+	}
+	p.Orders = orders
+	s.renderPage(w, r, http.StatusOK, "search", p)
+}
+
+// lookupOrder ищет заказ сначала в кэше, потом в бд.
+func (s *Server) lookupOrder(r *http.Request, id string) (order.Order, bool, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return order.Order{}, false, nil
+	}
+	if ord, ok := s.cache.Load(id); ok {
+		return ord, true, nil
+	}
+	ord, err := s.repo.FindById(r.Context(), id)
+	if errors.Is(err, order.ErrNotFound) {
+		return ord, false, nil
+	}
+	if err != nil {
+		return ord, false, err
+	}
+	return ord, true, nil
+}
+
+func (s *Server) renderPage(w http.ResponseWriter, r *http.Request, status int, name string, data any) {
+	var buf bytes.Buffer
+	if err := pageTemplates[name].ExecuteTemplate(&buf, "layout", data); err != nil {
+		s.logger.ErrorContext(r.Context(), "Ошибка рендеринга страницы", "error", err, "page", name)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,62 @@
+package serv_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"task1/internal/auth"
+	"task1/internal/config"
+)
+
+// TestRateLimitBeforeAuth: подбор ключей упирается в лимит по IP, а не
+// получает 401 без счёта; аутентифицированные клиенты ограничиваются по субъекту.
+func TestRateLimitBeforeAuth(t *testing.T) {
+	authMiddleware, err := auth.New(config.Auth{
+		Enabled: true,
+		APIKeys: []config.APIKey{{Name: "analyst", Key: "analyst-key", Roles: []string{"analyst"}}},
+		Roles:   map[string]config.Role{"analyst": {Routes: []string{"/api/v1/orders/"}}},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("auth.New: %v", err)
+	}
+	get := func(url, key string) int {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("X-API-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	limits := func(preAuth, perClient config.Limit) *config.Config {
+		return &config.Config{
+			HTTP:      config.HTTP{BatchGetMaxIDs: 100},
+			RateLimit: config.RateLimit{Enabled: true, Default: perClient, PreAuth: preAuth},
+		}
+	}
+
+	ts, id := newTestServerConfig(t, limits(config.Limit{RPS: 0.001, Burst: 3}, config.Limit{RPS: 100, Burst: 100}), authMiddleware)
+	var got []int
+	for range 5 {
+		got = append(got, get(ts.URL+"/api/v1/orders/"+id+"/status", "wrong-key"))
+	}
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized,
+		http.StatusTooManyRequests, http.StatusTooManyRequests}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ответы на неверный ключ: %v, want %v", got, want)
+		}
+	}
+
+	ts, id = newTestServerConfig(t, limits(config.Limit{RPS: 100, Burst: 100}, config.Limit{RPS: 0.001, Burst: 2}), authMiddleware)
+	got = got[:0]
+	for range 3 {
+		got = append(got, get(ts.URL+"/api/v1/orders/"+id+"/status", "analyst-key"))
+	}
+	if got[0] != http.StatusOK || got[1] != http.StatusOK || got[2] != http.StatusTooManyRequests {
+		t.Fatalf("ответы субъекту: %v", got)
+	}
+}
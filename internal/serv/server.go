@@ -6,56 +6,153 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"task1/internal/auth"
 	"task1/internal/cache"
+	"task1/internal/config"
+	"task1/internal/logging"
+	"task1/internal/metrics"
 	"task1/internal/order"
+	"task1/internal/ratelimit"
+	"task1/internal/redact"
+	"task1/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+var tracer = otel.Tracer("task1/internal/serv")
+
 type Server struct {
 	cache      cache.OrderCache
 	logger     *slog.Logger
 	repo       order.Repository
 	httpServer *http.Server
 	mux        *http.ServeMux
+	auth       *auth.Middleware
+	redactor   *redact.Redactor
+	// batchMaxIDs — максимум order_uid в одном запросе batchGet
+	batchMaxIDs int
+	rules       *order.Rules
 }
 
-func NewServer(cache cache.OrderCache, logger *slog.Logger, repo order.Repository, port int) *Server {
+func NewServer(cache cache.OrderCache, logger *slog.Logger, repo order.Repository, cfg *config.Config, authMiddleware *auth.Middleware, redactor *redact.Redactor, rules *order.Rules) *Server {
 	mux := http.NewServeMux()
+	limiter := ratelimit.New(cfg.RateLimit, logger)
+	// лимит по IP стоит до аутентификации: иначе неудачные попытки входа
+	// получают 401 раньше, чем их посчитает лимит
+	handler := logging.RequestID(limiter.WrapPreAuth(authMiddleware.Wrap(limiter.Wrap(limitBody(mux, cfg.HTTP.MaxBodyBytes)))))
 	server := &Server{
-		cache:  cache,
-		logger: logger,
-		repo:   repo,
-		mux:    mux,
+		cache:       cache,
+		logger:      logger,
+		repo:        repo,
+		mux:         mux,
+		auth:        authMiddleware,
+		redactor:    redactor,
+		batchMaxIDs: cfg.HTTP.BatchGetMaxIDs,
+		rules:       rules,
 		httpServer: &http.Server{
-			Addr:    fmt.Sprintf(":%d", port),
-			Handler: mux,
+			Addr:              fmt.Sprintf(":%d", cfg.Port),
+			Handler:           metrics.Middleware(tracing.Middleware(handler, routePattern(mux)), routePattern(mux)),
+			ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+			ReadTimeout:       cfg.HTTP.ReadTimeout,
+			WriteTimeout:      cfg.HTTP.WriteTimeout,
+			IdleTimeout:       cfg.HTTP.IdleTimeout,
+			MaxHeaderBytes:    cfg.HTTP.MaxHeaderBytes,
 		},
 	}
+	server.routes()
 	return server
 }
+func routePattern(mux *http.ServeMux) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			return pattern
+		}
+		return "unmatched"
+	}
+}
+
+func limitBody(next http.Handler, maxBytes int64) http.Handler {
+	if maxBytes <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	if id == "" {
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
+	ctx := logging.WithAttrs(r.Context(), "order_uid", id)
+	_, span := tracer.Start(ctx, "cache.load")
 	order, existInCache := s.cache.Load(id)
+	span.SetAttributes(attribute.String("order_uid", id), attribute.Bool("cache.hit", existInCache))
+	span.End()
 	w.Header().Set("Content-type", "application/json")
 	if existInCache {
-		json.NewEncoder(w).Encode(order)
+		json.NewEncoder(w).Encode(s.visibleOrder(r, order))
 		return
 	}
-	order, err := s.repo.FindById(context.Background(), id)
+	order, err := s.repo.FindById(ctx, id)
 	if err != nil {
-		s.logger.Error("Нету заказа в бд", "error", err)
-		w.WriteHeader(http.StatusNotFound)
+		if httpStatus(err) == http.StatusNotFound {
+			s.logger.InfoContext(ctx, "Нету заказа в бд")
+		} else {
+			s.logger.ErrorContext(ctx, "Ошибка поиска заказа в бд", "error", err)
+		}
+		writeError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(order)
+	json.NewEncoder(w).Encode(s.visibleOrder(r, order))
 
 }
-func (s *Server) Start() error {
+
+// visibleOrder маскирует PII, если у вызывающего нет права на их просмотр.
+func (s *Server) visibleOrder(r *http.Request, ord order.Order) order.Order {
+	if s.auth.HasPermission(r.Context(), s.redactor.UnmaskPermission()) {
+		return ord
+	}
+	return redact.Redact(s.redactor, ord)
+}
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// routes регистрирует обработчики API; main добавляет к ним свои через Handle.
+func (s *Server) routes() {
 	s.mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("./static"))))
 	s.mux.HandleFunc("/getOrder", s.getOrder)
+	s.mux.Handle("/assets/", assetsHandler())
+	s.mux.HandleFunc("GET /orders", s.searchPage)
+	s.mux.HandleFunc("GET /orders/{id}", s.orderPage)
+	s.mux.HandleFunc("/api/v1/orders:batchGet", s.batchGetOrders)
+	s.mux.HandleFunc("/api/v1/orders:export", s.exportOrders)
+	s.mux.HandleFunc("/api/v1/orders:validate", s.validateOrder)
+	s.mux.HandleFunc("GET /api/v1/orders/{id}/status", s.getOrderStatus)
+	s.mux.HandleFunc("POST /api/v1/orders/{id}/status", s.transitionOrderStatus)
+	s.mux.HandleFunc("GET /api/v1/orders/{id}/audit", s.getOrderAudit)
+	s.mux.HandleFunc("/api/openapi.json", s.getOpenAPI)
+	// страница документации лежит под /static/, /docs — короткий адрес той же страницы
+	s.mux.HandleFunc("GET /static/docs.html", s.getDocs)
+	s.mux.HandleFunc("GET /docs", s.getDocs)
+	s.mux.Handle("GET /docs/swagger-ui/", swaggerUIHandler())
+}
+
+// Handler возвращает обработчик со всеми middleware, как его обслуживает Start.
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+func (s *Server) Start() error {
 	err := s.httpServer.ListenAndServe()
 	if err != nil {
 		s.logger.Error("Ошибка запуска сервера", "error", err)
@@ -0,0 +1,104 @@
+package serv
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"task1/internal/auth"
+	"task1/internal/logging"
+	"task1/internal/order"
+)
+
+// statusPermission нужен для смены статуса; чтение истории доступно всем,
+// кому открыт маршрут.
+const statusPermission = "order_status"
+
+type statusResponse struct {
+	OrderUID    string               `json:"order_uid"`
+	Status      order.Status         `json:"status"`
+	History     []order.StatusChange `json:"history"`
+	Checkpoints []order.Checkpoint   `json:"checkpoints"`
+}
+
+type transitionRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+func (s *Server) getOrderStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), "order_uid", id)
+	history, err := s.repo.StatusHistory(ctx, id)
+	if err != nil {
+		if httpStatus(err) != http.StatusNotFound {
+			s.logger.ErrorContext(ctx, "Ошибка чтения истории статусов", "error", err)
+		}
+		writeError(w, err)
+		return
+	}
+	checkpoints, err := s.repo.Checkpoints(ctx, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Ошибка чтения точек маршрута", "error", err)
+		writeError(w, err)
+		return
+	}
+	if checkpoints == nil {
+		checkpoints = []order.Checkpoint{}
+	}
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(statusResponse{
+		OrderUID:    id,
+		Status:      history[len(history)-1].To,
+		History:     history,
+		Checkpoints: checkpoints,
+	})
+}
+
+func (s *Server) transitionOrderStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ctx := logging.WithAttrs(r.Context(), "order_uid", id)
+	// менять статус может только аутентифицированный вызывающий с правом
+	// order_status, в том числе когда аутентификация в конфиге выключена
+	if _, ok := auth.PrincipalFromContext(ctx); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !s.auth.HasPermission(ctx, statusPermission) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	var req transitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	to, err := order.ParseStatus(req.Status)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	change, err := s.repo.Transition(order.WithActor(ctx, apiActor(ctx)), id, to, req.Reason)
+	if err != nil {
+		if status := httpStatus(err); status != http.StatusNotFound && status != http.StatusConflict {
+			s.logger.ErrorContext(ctx, "Ошибка смены статуса заказа", "error", err)
+		}
+		writeError(w, err)
+		return
+	}
+	if ord, ok := s.cache.Load(id); ok {
+		ord.Status = change.To
+		s.cache.Store(ord)
+	}
+	s.logger.InfoContext(ctx, "Статус заказа изменён", "from", change.From, "to", change.To, "actor", change.Actor)
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+// apiActor — автор изменений для истории: субъект из аутентификации или
+// anonymous, если она выключена.
+func apiActor(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return "api:" + principal.Subject
+	}
+	return "api:anonymous"
+}
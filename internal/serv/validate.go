@@ -0,0 +1,47 @@
+package serv
+
+import (
+	"encoding/json"
+	"net/http"
+	"task1/internal/metrics"
+	"task1/internal/order"
+)
+
+type validateResponse struct {
+	Valid      bool              `json:"valid"`
+	Violations []order.Violation `json:"violations"`
+	Tags       []string          `json:"tags,omitempty"`
+}
+
+// validateOrder проверяет заказ теми же правилами, что и консьюмер, ничего
+// не сохраняя. Нарушения отдаются с уровнями; valid=false, если заказ был бы
+// отклонён.
+func (s *Server) validateOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var ord order.Order
+	if err := json.NewDecoder(r.Body).Decode(&ord); err != nil {
+		if httpStatus(err) == http.StatusUnprocessableEntity {
+			writeError(w, err)
+			return
+		}
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	_, span := tracer.Start(r.Context(), "order.rules")
+	report := s.rules.Check(ord)
+	span.End()
+	metrics.ObserveViolations(report.Violations)
+	resp := validateResponse{
+		Valid:      !report.Rejected(),
+		Violations: report.Violations,
+		Tags:       report.Tags(),
+	}
+	if resp.Violations == nil {
+		resp.Violations = []order.Violation{}
+	}
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
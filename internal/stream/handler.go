@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type Handler struct {
+	hub       *Hub
+	heartbeat time.Duration
+	upgrader  websocket.Upgrader
+	logger    *slog.Logger
+}
+
+func NewHandler(hub *Hub, heartbeat time.Duration, logger *slog.Logger) *Handler {
+	return &Handler{hub: hub, heartbeat: heartbeat, logger: logger}
+}
+
+// ServeHTTP отдаёт поток по SSE, а при запросе с Upgrade: websocket — по WebSocket.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	filter := Filter{DeliveryService: query.Get("delivery_service"), CustomerID: query.Get("customer_id")}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("last_event_id")
+	}
+	var lastEventID uint64
+	if lastID != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r, filter, lastEventID)
+		return
+	}
+	h.serveSSE(w, r, filter, lastEventID)
+}
+
+func (h *Handler) serveSSE(w http.ResponseWriter, r *http.Request, filter Filter, lastEventID uint64) {
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	sub, backlog := h.hub.Subscribe(filter, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+	write := func(event Event) error {
+		data, err := json.Marshal(event.Summary)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: order\ndata: %s\n\n", event.ID, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	for _, event := range backlog {
+		if err := write(event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Dropped():
+			fmt.Fprint(w, "event: dropped\ndata: slow consumer\n\n")
+			rc.Flush()
+			return
+		case event := <-sub.Events():
+			if err := write(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type wsMessage struct {
+	ID    uint64   `json:"id"`
+	Order *Summary `json:"order,omitempty"`
+	Type  string   `json:"type"`
+}
+
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request, filter Filter, lastEventID uint64) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Ошибка апгрейда до WebSocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	sub, backlog := h.hub.Subscribe(filter, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	write := func(msg wsMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(h.heartbeat))
+		return conn.WriteJSON(msg)
+	}
+	for _, event := range backlog {
+		if err := write(wsMessage{ID: event.ID, Type: "order", Order: &event.Summary}); err != nil {
+			return
+		}
+	}
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-sub.Dropped():
+			write(wsMessage{Type: "dropped"})
+			return
+		case event := <-sub.Events():
+			if err := write(wsMessage{ID: event.ID, Type: "order", Order: &event.Summary}); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(h.heartbeat))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
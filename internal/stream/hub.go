@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"log/slog"
+	"sync"
+	"task1/internal/money"
+	"task1/internal/order"
+	"time"
+)
+
+type Summary struct {
+	OrderUID        string      `json:"order_uid"`
+	TrackNumber     string      `json:"track_number"`
+	CustomerID      string      `json:"customer_id"`
+	DeliveryService string      `json:"delivery_service"`
+	Amount          money.Money `json:"amount"`
+	Currency        string      `json:"currency"`
+	ItemsCount      int         `json:"items_count"`
+	DateCreated     time.Time   `json:"date_created"`
+}
+
+type Event struct {
+	ID      uint64
+	Summary Summary
+}
+
+type Filter struct {
+	DeliveryService string
+	CustomerID      string
+}
+
+func (f Filter) match(s Summary) bool {
+	if f.DeliveryService != "" && f.DeliveryService != s.DeliveryService {
+		return false
+	}
+	if f.CustomerID != "" && f.CustomerID != s.CustomerID {
+		return false
+	}
+	return true
+}
+
+type Subscription struct {
+	filter Filter
+	events chan Event
+	// dropped закрывается, если клиент не успевает читать и был отключён.
+	dropped chan struct{}
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+// Hub раздаёт сводки новых заказов подписчикам и хранит последние события
+// в кольцевом буфере, чтобы клиент мог продолжить с Last-Event-ID.
+type Hub struct {
+	mu           sync.Mutex
+	ring         []Event
+	next         int
+	full         bool
+	lastID       uint64
+	subscribers  map[*Subscription]struct{}
+	clientBuffer int
+	logger       *slog.Logger
+}
+
+func NewHub(bufferSize, clientBuffer int, logger *slog.Logger) *Hub {
+	return &Hub{
+		ring:         make([]Event, bufferSize),
+		subscribers:  make(map[*Subscription]struct{}),
+		clientBuffer: clientBuffer,
+		logger:       logger,
+	}
+}
+
+func Summarize(ord order.Order) Summary {
+	s := Summary{
+		OrderUID:        ord.OrderUID,
+		TrackNumber:     ord.TrackNumber,
+		CustomerID:      ord.CustomerID,
+		DeliveryService: ord.DeliveryService,
+		ItemsCount:      len(ord.Items),
+		DateCreated:     ord.DateCreated,
+	}
+	if ord.Payment != nil {
+		s.Amount = ord.Payment.Amount
+		s.Currency = ord.Payment.Currency
+	}
+	return s
+}
+
+func (h *Hub) Publish(ord order.Order) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	event := Event{ID: h.lastID, Summary: Summarize(ord)}
+	if len(h.ring) > 0 {
+		h.ring[h.next] = event
+		h.next = (h.next + 1) % len(h.ring)
+		if h.next == 0 {
+			h.full = true
+		}
+	}
+	for sub := range h.subscribers {
+		if !sub.filter.match(event.Summary) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.logger.Warn("Клиент не успевает читать поток, отключаем", "last_event_id", event.ID)
+			delete(h.subscribers, sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+// Subscribe регистрирует подписчика и возвращает пропущенные события после lastEventID.
+// Подписку нужно закрыть через Unsubscribe.
+func (h *Hub) Subscribe(filter Filter, lastEventID uint64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub := &Subscription{filter: filter, events: make(chan Event, h.clientBuffer), dropped: make(chan struct{})}
+	h.subscribers[sub] = struct{}{}
+	if lastEventID == 0 {
+		return sub, nil
+	}
+	var backlog []Event
+	for _, event := range h.buffered() {
+		if event.ID > lastEventID && filter.match(event.Summary) {
+			backlog = append(backlog, event)
+		}
+	}
+	return sub, backlog
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.dropped)
+	}
+}
+
+func (h *Hub) buffered() []Event {
+	if !h.full {
+		return h.ring[:h.next]
+	}
+	out := make([]Event, 0, len(h.ring))
+	out = append(out, h.ring[h.next:]...)
+	return append(out, h.ring[:h.next]...)
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+)
+
+// headerCarrier позволяет пропагатору читать и писать заголовки сообщения Kafka.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+func InjectKafka(ctx context.Context, msg *kafka.Message) {
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{headers: &msg.Headers})
+}
+
+func ExtractKafka(ctx context.Context, msg *kafka.Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &msg.Headers})
+}
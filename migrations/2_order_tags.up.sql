@@ -0,0 +1,4 @@
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS orders_tags_idx ON orders USING GIN (tags);
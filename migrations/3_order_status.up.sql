@@ -0,0 +1,21 @@
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'created'
+    CHECK (status IN ('created', 'paid', 'assembled', 'shipped', 'delivered', 'cancelled', 'returned'));
+
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid UUID NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    reason TEXT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_uid_idx ON order_status_history (order_uid, changed_at);
+
+INSERT INTO order_status_history (order_uid, to_status, actor, changed_at)
+SELECT o.order_uid, 'created', 'migration', COALESCE(o.date_created, now())
+FROM orders o
+WHERE NOT EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_uid = o.order_uid);
@@ -0,0 +1,24 @@
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_event_at TIMESTAMPTZ;
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS status_event_at TIMESTAMPTZ;
+
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS event_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS orders_track_number_idx ON orders (track_number);
+
+CREATE INDEX IF NOT EXISTS items_order_uid_idx ON items (order_uid);
+
+CREATE INDEX IF NOT EXISTS payment_order_uid_idx ON payment (order_uid);
+
+
+CREATE TABLE IF NOT EXISTS delivery_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid UUID NOT NULL REFERENCES orders(order_uid) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    location TEXT,
+    description TEXT,
+    source TEXT,
+    event_time TIMESTAMPTZ NOT NULL,
+    UNIQUE (order_uid, code, event_time)
+);
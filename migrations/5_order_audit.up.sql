@@ -0,0 +1,27 @@
+
+-- без внешнего ключа: журнал переживает удаление заказа
+CREATE TABLE IF NOT EXISTS order_audit (
+    id BIGSERIAL PRIMARY KEY,
+    order_uid UUID NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    trace_id TEXT,
+    diff JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS order_audit_order_uid_idx ON order_audit (order_uid, id);
+
+
+CREATE OR REPLACE FUNCTION order_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'order_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS order_audit_append_only ON order_audit;
+
+CREATE TRIGGER order_audit_append_only
+    BEFORE UPDATE OR DELETE ON order_audit
+    FOR EACH ROW EXECUTE FUNCTION order_audit_append_only();
@@ -0,0 +1,169 @@
+
+-- Помесячное секционирование по date_created. Ключ секционирования входит
+-- во все первичные и внешние ключи, поэтому date_created есть и в дочерних
+-- таблицах и совпадает с датой заказа.
+
+ALTER TABLE orders RENAME TO orders_legacy;
+ALTER TABLE delivery RENAME TO delivery_legacy;
+ALTER TABLE payment RENAME TO payment_legacy;
+ALTER TABLE items RENAME TO items_legacy;
+
+ALTER INDEX IF EXISTS orders_pkey RENAME TO orders_legacy_pkey;
+ALTER INDEX IF EXISTS delivery_pkey RENAME TO delivery_legacy_pkey;
+ALTER INDEX IF EXISTS payment_pkey RENAME TO payment_legacy_pkey;
+ALTER INDEX IF EXISTS items_pkey RENAME TO items_legacy_pkey;
+ALTER INDEX IF EXISTS orders_tags_idx RENAME TO orders_legacy_tags_idx;
+ALTER INDEX IF EXISTS orders_track_number_idx RENAME TO orders_legacy_track_number_idx;
+ALTER INDEX IF EXISTS items_order_uid_idx RENAME TO items_legacy_order_uid_idx;
+ALTER INDEX IF EXISTS payment_order_uid_idx RENAME TO payment_legacy_order_uid_idx;
+
+
+CREATE TABLE orders (
+    order_uid UUID NOT NULL DEFAULT gen_random_uuid(),
+    track_number TEXT NOT NULL,
+    entry TEXT NOT NULL,
+    locale TEXT,
+    internal_signature TEXT,
+    customer_id TEXT,
+    delivery_service TEXT,
+    shardkey TEXT,
+    sm_id INTEGER,
+    date_created TIMESTAMP NOT NULL DEFAULT now(),
+    oof_shard TEXT,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'created'
+        CHECK (status IN ('created', 'paid', 'assembled', 'shipped', 'delivered', 'cancelled', 'returned')),
+    status_event_at TIMESTAMPTZ,
+    PRIMARY KEY (order_uid, date_created)
+) PARTITION BY RANGE (date_created);
+
+
+CREATE TABLE delivery (
+    delivery_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    order_uid UUID NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    name TEXT,
+    phone TEXT,
+    zip TEXT,
+    city TEXT,
+    address TEXT,
+    region TEXT,
+    email TEXT,
+    PRIMARY KEY (delivery_id, date_created),
+    FOREIGN KEY (order_uid, date_created) REFERENCES orders (order_uid, date_created) ON DELETE CASCADE
+) PARTITION BY RANGE (date_created);
+
+
+CREATE TABLE payment (
+    payment_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    order_uid UUID NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    transaction TEXT,
+    request_id TEXT,
+    currency TEXT,
+    provider TEXT,
+    amount NUMERIC,
+    payment_dt BIGINT,
+    bank TEXT,
+    delivery_cost NUMERIC,
+    goods_total NUMERIC,
+    custom_fee NUMERIC,
+    event_at TIMESTAMPTZ,
+    PRIMARY KEY (payment_id, date_created),
+    FOREIGN KEY (order_uid, date_created) REFERENCES orders (order_uid, date_created) ON DELETE CASCADE
+) PARTITION BY RANGE (date_created);
+
+
+CREATE TABLE items (
+    item_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    order_uid UUID NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    chrt_id BIGINT,
+    track_number TEXT,
+    price NUMERIC,
+    rid TEXT,
+    name TEXT,
+    sale NUMERIC,
+    size TEXT,
+    total_price NUMERIC,
+    nm_id BIGINT,
+    brand TEXT,
+    status INTEGER,
+    status_event_at TIMESTAMPTZ,
+    PRIMARY KEY (item_id, date_created),
+    FOREIGN KEY (order_uid, date_created) REFERENCES orders (order_uid, date_created) ON DELETE CASCADE
+) PARTITION BY RANGE (date_created);
+
+
+-- PRIMARY KEY начинается с order_uid только у orders, дочерним нужен свой индекс
+CREATE INDEX orders_track_number_idx ON orders (track_number);
+CREATE INDEX orders_tags_idx ON orders USING GIN (tags);
+CREATE INDEX delivery_order_uid_idx ON delivery (order_uid);
+CREATE INDEX payment_order_uid_idx ON payment (order_uid);
+CREATE INDEX items_order_uid_idx ON items (order_uid);
+
+
+-- create_order_partitions создаёт секции всех четырёх таблиц за месяц month;
+-- имена вида orders_y2024m01. Повторный вызов ничего не меняет.
+CREATE OR REPLACE FUNCTION create_order_partitions(month DATE) RETURNS void AS $$
+DECLARE
+    from_date DATE := date_trunc('month', month);
+    to_date DATE := date_trunc('month', month) + INTERVAL '1 month';
+    tbl TEXT;
+BEGIN
+    FOREACH tbl IN ARRAY ARRAY['orders', 'delivery', 'payment', 'items'] LOOP
+        EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+            tbl || to_char(from_date, '"_y"YYYY"m"MM'), tbl, from_date, to_date);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+
+-- секции по умолчанию принимают даты, для которых месяц ещё не создан
+CREATE TABLE orders_default PARTITION OF orders DEFAULT;
+CREATE TABLE delivery_default PARTITION OF delivery DEFAULT;
+CREATE TABLE payment_default PARTITION OF payment DEFAULT;
+CREATE TABLE items_default PARTITION OF items DEFAULT;
+
+
+SELECT create_order_partitions(month::date)
+FROM generate_series(
+    date_trunc('month', LEAST(COALESCE((SELECT min(date_created) FROM orders_legacy), now()), now())),
+    date_trunc('month', now()) + INTERVAL '2 months',
+    INTERVAL '1 month'
+) AS month;
+
+
+INSERT INTO orders (
+    order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service,
+    shardkey, sm_id, date_created, oof_shard, tags, status, status_event_at
+)
+SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service,
+    shardkey, sm_id, COALESCE(date_created, now()), oof_shard, tags, status, status_event_at
+FROM orders_legacy;
+
+INSERT INTO delivery (delivery_id, order_uid, date_created, name, phone, zip, city, address, region, email)
+SELECT d.delivery_id, d.order_uid, o.date_created, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email
+FROM delivery_legacy d JOIN orders o ON o.order_uid = d.order_uid;
+
+INSERT INTO payment (
+    payment_id, order_uid, date_created, transaction, request_id, currency, provider,
+    amount, payment_dt, bank, delivery_cost, goods_total, custom_fee, event_at
+)
+SELECT p.payment_id, p.order_uid, o.date_created, p.transaction, p.request_id, p.currency, p.provider,
+    p.amount, p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee, p.event_at
+FROM payment_legacy p JOIN orders o ON o.order_uid = p.order_uid;
+
+INSERT INTO items (
+    item_id, order_uid, date_created, chrt_id, track_number, price, rid, name,
+    sale, size, total_price, nm_id, brand, status, status_event_at
+)
+SELECT i.item_id, i.order_uid, o.date_created, i.chrt_id, i.track_number, i.price, i.rid, i.name,
+    i.sale, i.size, i.total_price, i.nm_id, i.brand, i.status, i.status_event_at
+FROM items_legacy i JOIN orders o ON o.order_uid = i.order_uid;
+
+
+-- order_uid больше не уникален сам по себе, поэтому внешние ключи истории
+-- статусов и точек маршрута снимаются вместе со старыми таблицами; эти строки
+-- удаляет задача хранения вместе с секцией заказа.
+DROP TABLE items_legacy, payment_legacy, delivery_legacy, orders_legacy CASCADE;
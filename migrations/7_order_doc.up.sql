@@ -0,0 +1,22 @@
+
+-- Заказ целиком для режимов хранения dual и document. Выражение совпадает
+-- с orderDocSQL в internal/order/db/document.go.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS doc JSONB;
+
+-- времена последних событий товаров и оплаты для режима document
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS doc_event_at JSONB NOT NULL DEFAULT '{}';
+
+UPDATE orders o SET doc = jsonb_build_object(
+    'order_uid', o.order_uid, 'track_number', o.track_number, 'entry', o.entry,
+    'locale', o.locale, 'internal_signature', o.internal_signature,
+    'customer_id', o.customer_id, 'delivery_service', o.delivery_service,
+    'shardkey', o.shardkey, 'sm_id', o.sm_id,
+    'date_created', to_char(o.date_created, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+    'oof_shard', o.oof_shard, 'status', o.status, 'tags', to_jsonb(o.tags),
+    'delivery', (SELECT to_jsonb(d) - 'order_uid' - 'date_created' FROM delivery d
+        WHERE d.order_uid = o.order_uid AND d.date_created = o.date_created LIMIT 1),
+    'payment', (SELECT to_jsonb(p) - 'order_uid' - 'date_created' - 'event_at' FROM payment p
+        WHERE p.order_uid = o.order_uid AND p.date_created = o.date_created LIMIT 1),
+    'items', COALESCE((SELECT jsonb_agg(to_jsonb(i) - 'order_uid' - 'date_created' - 'status_event_at' ORDER BY i.item_id)
+        FROM items i WHERE i.order_uid = o.order_uid AND i.date_created = o.date_created), '[]'::jsonb))
+WHERE o.doc IS NULL;
@@ -0,0 +1,32 @@
+-- create_order_partitions не мог создать секцию месяца, если в секции по
+-- умолчанию уже лежат строки этого месяца. Теперь такие строки переносятся
+-- во временные таблицы, секции создаются и строки возвращаются в них.
+-- Если секция orders месяца уже есть (в том числе отсоединённая и ещё не
+-- удалённая задачей retention), функция ничего не делает.
+CREATE OR REPLACE FUNCTION create_order_partitions(month DATE) RETURNS void AS $$
+DECLARE
+    from_date DATE := date_trunc('month', month);
+    to_date DATE := date_trunc('month', month) + INTERVAL '1 month';
+    suffix TEXT := to_char(date_trunc('month', month), '"_y"YYYY"m"MM');
+    tbl TEXT;
+BEGIN
+    IF to_regclass('orders' || suffix) IS NOT NULL THEN
+        RETURN;
+    END IF;
+
+    -- дочерние таблицы первыми: на строки orders_default ссылаются внешние ключи
+    FOREACH tbl IN ARRAY ARRAY['items', 'payment', 'delivery', 'orders'] LOOP
+        EXECUTE format('CREATE TEMP TABLE %I (LIKE %I)', 'moved_' || tbl || suffix, tbl);
+        EXECUTE format('WITH moved AS (DELETE FROM %I WHERE date_created >= %L AND date_created < %L RETURNING *)
+            INSERT INTO %I SELECT * FROM moved',
+            tbl || '_default', from_date, to_date, 'moved_' || tbl || suffix);
+    END LOOP;
+
+    FOREACH tbl IN ARRAY ARRAY['orders', 'delivery', 'payment', 'items'] LOOP
+        EXECUTE format('CREATE TABLE IF NOT EXISTS %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+            tbl || suffix, tbl, from_date, to_date);
+        EXECUTE format('INSERT INTO %I SELECT * FROM %I', tbl, 'moved_' || tbl || suffix);
+        EXECUTE format('DROP TABLE %I', 'moved_' || tbl || suffix);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
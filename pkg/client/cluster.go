@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReadRouter выбирает, куда отправить чтение, которое может обслужить реплика.
+// keys — ключи читаемых записей (order_uid): если какой-то из них недавно
+// записан, чтение идёт в основную бд.
+type ReadRouter interface {
+	Reader(ctx context.Context, keys ...string) CLient
+}
+
+// Options — основная бд и реплики для чтения.
+type Options struct {
+	PrimaryDSN  string
+	ReplicaDSNs []string
+	// HealthInterval — как часто пинговать реплики.
+	HealthInterval time.Duration
+	// ReadYourWrites — сколько после записи ключа читать его с основной бд,
+	// пока реплики догоняют. 0 — сразу читать с реплик.
+	ReadYourWrites time.Duration
+}
+
+// OptionsFromEnv читает DB_DSN (по умолчанию собирается из DB_HOST и
+// остальных DB_*), DB_REPLICA_DSNS через запятую, DB_HEALTH_INTERVAL и
+// DB_READ_YOUR_WRITES.
+func OptionsFromEnv() (Options, error) {
+	opts := Options{
+		PrimaryDSN:     os.Getenv("DB_DSN"),
+		HealthInterval: 5 * time.Second,
+		ReadYourWrites: 2 * time.Second,
+	}
+	if opts.PrimaryDSN == "" {
+		opts.PrimaryDSN = dsnFromEnv()
+	}
+	for _, dsn := range strings.Split(os.Getenv("DB_REPLICA_DSNS"), ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			opts.ReplicaDSNs = append(opts.ReplicaDSNs, dsn)
+		}
+	}
+	for _, d := range []struct {
+		env string
+		dst *time.Duration
+	}{{"DB_HEALTH_INTERVAL", &opts.HealthInterval}, {"DB_READ_YOUR_WRITES", &opts.ReadYourWrites}} {
+		value := os.Getenv(d.env)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return opts, fmt.Errorf("%s: %w", d.env, err)
+		}
+		*d.dst = parsed
+	}
+	return opts, nil
+}
+
+func dsnFromEnv() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s", os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
+}
+
+// Cluster отправляет запись и транзакции в основную бд, а чтения через
+// Reader — в здоровые реплики по кругу. Без реплик это просто основной пул.
+type Cluster struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+	opts     Options
+	logger   *slog.Logger
+
+	// writes — время последней записи по ключу, только за ReadYourWrites;
+	// старые ключи вычищаются при записи не чаще раза в ReadYourWrites.
+	mu        sync.Mutex
+	writes    map[string]time.Time
+	lastSweep time.Time
+}
+
+type replica struct {
+	pool *pgxpool.Pool
+	// ping проверяет реплику; это pool.Ping, в тестах — заглушка
+	ping    func(context.Context) error
+	healthy atomic.Bool
+}
+
+// NewCluster подключается к основной бд и репликам и проверяет реплики раз
+// в HealthInterval до отмены ctx. Недоступная реплика не мешает запуску:
+// она вернётся в работу после успешной проверки.
+func NewCluster(ctx context.Context, opts Options, logger *slog.Logger) (*Cluster, error) {
+	connectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	primary, err := pgxpool.New(connectCtx, opts.PrimaryDSN)
+	if err != nil {
+		logger.Error("Ошибка подключения к PSQL", "error", err.Error())
+		return nil, err
+	}
+	c := &Cluster{primary: primary, opts: opts, logger: logger, writes: make(map[string]time.Time)}
+	for _, dsn := range opts.ReplicaDSNs {
+		pool, err := pgxpool.New(connectCtx, dsn)
+		if err != nil {
+			logger.Error("Ошибка подключения к реплике PSQL", "error", err.Error())
+			c.Close()
+			return nil, err
+		}
+		c.replicas = append(c.replicas, &replica{pool: pool, ping: pool.Ping})
+	}
+	if len(c.replicas) > 0 {
+		c.checkReplicas(ctx)
+		go c.watchReplicas(ctx)
+	}
+	return c, nil
+}
+
+// NewClusterFromEnv — NewCluster с настройками из OptionsFromEnv, для
+// команд, которым не нужно ничего, кроме подключения.
+func NewClusterFromEnv(ctx context.Context, logger *slog.Logger) (*Cluster, error) {
+	opts, err := OptionsFromEnv()
+	if err != nil {
+		logger.Error("Ошибка настройки подключения к бд", "error", err)
+		return nil, err
+	}
+	return NewCluster(ctx, opts, logger)
+}
+
+// Primary возвращает пул основной бд для миграций, метрик и DDL.
+func (c *Cluster) Primary() *pgxpool.Pool {
+	return c.primary
+}
+
+// Reader возвращает реплику по кругу среди здоровых. Основная бд — если
+// реплик нет, все недоступны или после записи одного из keys не прошло
+// ReadYourWrites. Чтения без ключей (списки, поиск) всегда идут в реплики.
+func (c *Cluster) Reader(ctx context.Context, keys ...string) CLient {
+	if len(c.replicas) == 0 {
+		return c.primary
+	}
+	if c.recentlyWritten(keys) {
+		return c.primary
+	}
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return c.primary
+}
+
+func (c *Cluster) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return c.primary.Exec(ctx, sql, arguments...)
+}
+
+func (c *Cluster) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return c.primary.Query(ctx, sql, args...)
+}
+
+func (c *Cluster) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return c.primary.QueryRow(ctx, sql, args...)
+}
+
+// Begin открывает транзакцию в основной бд. Ключи, отмеченные в ней через
+// MarkWritten, после коммита читаются с основной бд.
+func (c *Cluster) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := c.primary.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &writeTx{Tx: tx, cluster: c}, nil
+}
+
+func (c *Cluster) Ping(ctx context.Context) error {
+	return c.primary.Ping(ctx)
+}
+
+func (c *Cluster) Close() {
+	for _, r := range c.replicas {
+		r.pool.Close()
+	}
+	c.primary.Close()
+}
+
+func (c *Cluster) markWritten(keys []string) {
+	if c.opts.ReadYourWrites <= 0 || len(c.replicas) == 0 || len(keys) == 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.writes[key] = now
+	}
+	if now.Sub(c.lastSweep) < c.opts.ReadYourWrites {
+		return
+	}
+	c.lastSweep = now
+	for key, at := range c.writes {
+		if now.Sub(at) >= c.opts.ReadYourWrites {
+			delete(c.writes, key)
+		}
+	}
+}
+
+func (c *Cluster) recentlyWritten(keys []string) bool {
+	if c.opts.ReadYourWrites <= 0 || len(keys) == 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if at, ok := c.writes[key]; ok && time.Since(at) < c.opts.ReadYourWrites {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cluster) watchReplicas(ctx context.Context) {
+	ticker := time.NewTicker(c.opts.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.checkReplicas(ctx)
+		}
+	}
+}
+
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	for i, r := range c.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := r.ping(pingCtx)
+		cancel()
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				c.logger.Info("Реплика PSQL доступна", "replica", i)
+			} else {
+				c.logger.Warn("Реплика PSQL недоступна, чтения идут на другие", "replica", i, "error", err)
+			}
+		}
+	}
+}
+
+// writeTx отмечает ключи записи после успешного коммита, чтобы следующие
+// чтения этих ключей увидели её на основной бд.
+type writeTx struct {
+	pgx.Tx
+	cluster *Cluster
+	keys    []string
+}
+
+func (t *writeTx) Commit(ctx context.Context) error {
+	err := t.Tx.Commit(ctx)
+	if err == nil {
+		t.cluster.markWritten(t.keys)
+	}
+	return err
+}
+
+// MarkWritten отмечает, что транзакция tx меняет записи keys. Для транзакций
+// не из Cluster ничего не делает.
+func MarkWritten(tx pgx.Tx, keys ...string) {
+	if t, ok := tx.(*writeTx); ok {
+		t.keys = append(t.keys, keys...)
+	}
+}
@@ -2,15 +2,9 @@ package client
 
 import (
 	"context"
-	"fmt"
-	"log/slog"
-	"os"
-
-	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type CLient interface {
@@ -18,17 +12,5 @@ type CLient interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Begin(ctx context.Context) (pgx.Tx, error)
-}
-
-func NewCLient(ctx context.Context, logger *slog.Logger) (pool *pgxpool.Pool, err error) {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-	pool, err = pgxpool.New(ctx, dsn)
-	if err != nil {
-		logger.Error("Ошибка подключения к PSQL %s", "error", err.Error())
-		return nil, err
-	}
-	return pool, err
+	Ping(ctx context.Context) error
 }
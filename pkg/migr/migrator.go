@@ -1,12 +1,18 @@
 package migr
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 )
@@ -45,3 +51,49 @@ func (m *Migrator) Migrate(path string) error {
 
 	return nil
 }
+
+var migrationFile = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// ExpectedVersion возвращает номер последней миграции в каталоге path.
+func ExpectedVersion(path string) (uint, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		latest = max(latest, uint(version))
+	}
+	return latest, nil
+}
+
+func (m *Migrator) CheckVersion(ctx context.Context, path string) error {
+	expected, err := ExpectedVersion(path)
+	if err != nil {
+		return err
+	}
+	var version int64
+	var dirty bool
+	err = m.Pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("миграции не применены, ожидается версия %d", expected)
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("миграция %d в состоянии dirty", version)
+	}
+	if uint(version) != expected {
+		return fmt.Errorf("версия схемы %d, ожидается %d", version, expected)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package orderclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("заказ не найден")
+
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("неожиданный ответ сервера %d: %s", e.StatusCode, e.Body)
+}
+
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	apiKey      string
+	bearerToken string
+}
+
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+func (c *Client) SetAPIKey(key string) {
+	c.apiKey = key
+}
+
+func (c *Client) SetBearerToken(token string) {
+	c.bearerToken = token
+}
+
+func (c *Client) GetOrder(ctx context.Context, id string) (*Order, error) {
+	var ord Order
+	if err := c.get(ctx, "/getOrder?id="+url.QueryEscape(id), &ord); err != nil {
+		return nil, err
+	}
+	return &ord, nil
+}
+
+// BatchGetOrders возвращает результат по каждому запрошенному id:
+// либо заказ, либо ошибку (invalid_id, not_found, unavailable).
+func (c *Client) BatchGetOrders(ctx context.Context, ids []string) (map[string]BatchGetResult, error) {
+	body, err := json.Marshal(map[string][]string{"order_uids": ids})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/orders:batchGet", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var resp struct {
+		Orders map[string]BatchGetResult `json:"orders"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Orders, nil
+}
+
+// ValidateOrder проверяет заказ бизнес-правилами сервиса, не сохраняя его.
+// order — любое значение, которое кодируется в JSON заказа.
+func (c *Client) ValidateOrder(ctx context.Context, order any) (*ValidationResult, error) {
+	body, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/orders:validate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var result ValidationResult
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetOrderStatus возвращает текущий статус заказа и историю переходов.
+func (c *Client) GetOrderStatus(ctx context.Context, id string) (*OrderStatus, error) {
+	var status OrderStatus
+	if err := c.get(ctx, "/api/v1/orders/"+url.PathEscape(id)+"/status", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetOrderAudit возвращает журнал изменений заказа в порядке записи.
+func (c *Client) GetOrderAudit(ctx context.Context, id string) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	if err := c.get(ctx, "/api/v1/orders/"+url.PathEscape(id)+"/audit", &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// TransitionOrderStatus переводит заказ в статус to. Запрещённый переход
+// возвращается как *StatusError с кодом 409.
+func (c *Client) TransitionOrderStatus(ctx context.Context, id, to, reason string) (*StatusChange, error) {
+	body, err := json.Marshal(map[string]string{"status": to, "reason": reason})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/orders/"+url.PathEscape(id)+"/status", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var change StatusChange
+	if err := c.do(req, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]any, error) {
+	spec := make(map[string]any)
+	if err := c.get(ctx, "/api/openapi.json", &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			statusErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return statusErr
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
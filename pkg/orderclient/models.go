@@ -0,0 +1,134 @@
+package orderclient
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы повторяют components/schemas из internal/serv/openapi.json.
+// При изменении спецификации их нужно обновлять вместе с ней.
+// Суммы приходят в основных единицах валюты и могут быть дробными;
+// json.Number сохраняет их без потери точности.
+
+type Order struct {
+	OrderUID          string    `json:"order_uid"`
+	TrackNumber       string    `json:"track_number"`
+	Entry             string    `json:"entry"`
+	Locale            string    `json:"locale"`
+	InternalSignature string    `json:"internal_signature"`
+	CustomerID        string    `json:"customer_id"`
+	DeliveryService   string    `json:"delivery_service"`
+	ShardKey          string    `json:"shardkey"`
+	SmID              int       `json:"sm_id"`
+	DateCreated       time.Time `json:"date_created"`
+	OofShard          string    `json:"oof_shard"`
+	Status            string    `json:"status,omitempty"`
+	Delivery          *Delivery `json:"delivery"`
+	Payment           *Payment  `json:"payment"`
+	Items             []*Item   `json:"items"`
+	Tags              []string  `json:"tags,omitempty"`
+}
+
+type Delivery struct {
+	DeliveryID string `json:"delivery_id"`
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+	Zip        string `json:"zip"`
+	City       string `json:"city"`
+	Address    string `json:"address"`
+	Region     string `json:"region"`
+	Email      string `json:"email"`
+}
+
+type Payment struct {
+	PaymentID    string      `json:"payment_id"`
+	Transaction  string      `json:"transaction"`
+	RequestID    string      `json:"request_id"`
+	Currency     string      `json:"currency"`
+	Provider     string      `json:"provider"`
+	Amount       json.Number `json:"amount"`
+	PaymentDT    int         `json:"payment_dt"`
+	Bank         string      `json:"bank"`
+	DeliveryCost json.Number `json:"delivery_cost"`
+	GoodsTotal   json.Number `json:"goods_total"`
+	CustomFee    json.Number `json:"custom_fee"`
+}
+
+type Item struct {
+	ItemID      string      `json:"item_id"`
+	ChrtID      int         `json:"chrt_id"`
+	TrackNumber string      `json:"track_number"`
+	Price       json.Number `json:"price"`
+	Rid         string      `json:"rid"`
+	Name        string      `json:"name"`
+	Sale        int         `json:"sale"`
+	Size        string      `json:"size"`
+	TotalPrice  json.Number `json:"total_price"`
+	NmID        int         `json:"nm_id"`
+	Brand       string      `json:"brand"`
+	Status      int         `json:"status"`
+}
+
+type BatchGetResult struct {
+	Order *Order         `json:"order,omitempty"`
+	Error *BatchGetError `json:"error,omitempty"`
+}
+
+type BatchGetError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationResult struct {
+	Valid      bool        `json:"valid"`
+	Violations []Violation `json:"violations"`
+	Tags       []string    `json:"tags,omitempty"`
+}
+
+type Violation struct {
+	Rule     string `json:"rule"`
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message,omitempty"`
+}
+
+type OrderStatus struct {
+	OrderUID    string         `json:"order_uid"`
+	Status      string         `json:"status"`
+	History     []StatusChange `json:"history"`
+	Checkpoints []Checkpoint   `json:"checkpoints"`
+}
+
+type Checkpoint struct {
+	Code        string    `json:"code"`
+	Location    string    `json:"location,omitempty"`
+	Description string    `json:"description,omitempty"`
+	EventTime   time.Time `json:"event_time"`
+	Source      string    `json:"source,omitempty"`
+}
+
+type StatusChange struct {
+	OrderUID  string    `json:"order_uid"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// AuditEntry — запись журнала изменений; Diff по json-пути поля.
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	OrderUID  string                 `json:"order_uid"`
+	Operation string                 `json:"operation"`
+	Actor     string                 `json:"actor"`
+	RequestID string                 `json:"request_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
+	Diff      map[string]FieldChange `json:"diff"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}